@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/roboticeyes/gococo/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -23,6 +25,11 @@ var (
 			log.FieldKeyFunc:  "logger_name",
 		},
 	}
+
+	httpRequestsTotal = metrics.NewCounter(metrics.Namespace+"_http_requests_total",
+		"Number of handled HTTP requests by method, route and status code.", "method", "route", "code")
+	httpRequestDuration = metrics.NewHistogram(metrics.Namespace+"_http_request_duration_seconds",
+		"Duration of handled HTTP requests.", nil, "method", "route")
 )
 
 // Fields type, used to pass to `WithFields`. Forwarded from logrus library
@@ -50,6 +57,7 @@ func init() {
 		ExitFunc:     os.Exit,
 		ReportCaller: false,
 	}
+	Log.AddHook(requestIDHook{})
 }
 
 // ConfigureLogging configures Log to write event logs compliant to our journal system
@@ -73,6 +81,7 @@ func Logger(logger log.FieldLogger) gin.HandlerFunc {
 		stop := time.Since(start)
 		latency := int(math.Ceil(float64(stop.Nanoseconds()) / 1000000.0))
 		statusCode := c.Writer.Status()
+		observeRequest(c, statusCode, stop)
 		clientIP := c.ClientIP()
 		clientUserAgent := c.Request.UserAgent()
 		dataLength := c.Writer.Size()
@@ -90,6 +99,10 @@ func Logger(logger log.FieldLogger) gin.HandlerFunc {
 			"http_x_forwarded_for": clientIP,
 		})
 
+		if requestID := c.GetString(RequestIDKey); requestID != "" {
+			entry = entry.WithField("request_id", requestID)
+		}
+
 		if userID != "" {
 			entry.WithField("username", userID)
 		}
@@ -108,3 +121,21 @@ func Logger(logger log.FieldLogger) gin.HandlerFunc {
 		}
 	}
 }
+
+// RegisterMetrics registers the metrics of the requests handled by Logger at the given registry,
+// e.g. metrics.DefaultRegistry. The metrics are recorded in any case, but only exposed once
+// registered.
+func RegisterMetrics(registry *metrics.Registry) error {
+	return registry.Register(httpRequestsTotal, httpRequestDuration)
+}
+
+// observeRequest records the metrics of the handled request. The route is used instead of the
+// path, so that path parameters do not create a series per resource.
+func observeRequest(c *gin.Context, statusCode int, d time.Duration) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestsTotal.Inc(c.Request.Method, route, strconv.Itoa(statusCode))
+	httpRequestDuration.Observe(d.Seconds(), c.Request.Method, route)
+}
@@ -0,0 +1,96 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIDHeader is the header field which carries the request ID across services
+	RequestIDHeader = "X-Request-ID"
+
+	// RequestIDKey is the key of the request ID in the gin context
+	RequestIDKey = "RequestID"
+
+	// maxRequestIDLength limits the length of request IDs taken over from the caller
+	maxRequestIDLength = 128
+)
+
+// requestIDKey is used for storing the request ID in the context
+type requestIDKey struct{}
+
+// RequestID is the gin handler which reads the request ID of the caller from the X-Request-ID
+// header or generates a new one. The ID is stored in the gin context and in the context of the
+// request, and it is returned in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Request = c.Request.WithContext(ContextWithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// ContextWithRequestID returns a new context which carries the request ID. All entries which
+// are logged with this context contain the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of the context or of the gin context. An empty
+// string is returned if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	// gin contexts expose their keys by string
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// requestIDHook adds the request ID of the context to the entries logged with a context
+type requestIDHook struct{}
+
+func (requestIDHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (requestIDHook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := RequestIDFromContext(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+	return nil
+}
+
+// isValidRequestID accepts IDs which can be logged safely
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID generates a random UUID (version 4)
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,48 @@
+package event
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logged bytes.Buffer
+	logger := log.New()
+	logger.Out = &logged
+	logger.Formatter = formatter
+	logger.AddHook(requestIDHook{})
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		logger.WithContext(c.Request.Context()).Info("handled")
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	router.ServeHTTP(w, req)
+	if w.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Fatal("Request ID of the caller not kept", w.Header())
+	}
+	if !strings.Contains(logged.String(), `"request_id":"abc-123"`) {
+		t.Fatal("Request ID not logged", logged.String())
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "bad\nid")
+	router.ServeHTTP(w, req)
+	if id := w.Header().Get(RequestIDHeader); len(id) != 36 {
+		t.Fatal("No request ID generated", id)
+	}
+}
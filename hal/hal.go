@@ -0,0 +1,297 @@
+// Package hal decodes documents in the Hypertext Application Language (HAL) as served by REXos.
+// Links are looked up by their relation, also if it is given as CURIE, and link templates are
+// expanded according to RFC 6570. Embedded resources can be decoded into typed Go structs.
+package hal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// RelSelf is the relation of the link to the resource itself
+	RelSelf = "self"
+
+	// RelCuries is the relation of the CURIE definitions
+	RelCuries = "curies"
+
+	// curieRel is the variable of a CURIE template which is replaced by the reference
+	curieRel = "rel"
+)
+
+// ErrNoLink is returned if a resource has no link with the requested relation
+var ErrNoLink = errors.New("No link with the relation")
+
+// Link is a link of a HAL resource
+type Link struct {
+	Href        string `json:"href"`
+	Templated   bool   `json:"templated,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Hreflang    string `json:"hreflang,omitempty"`
+}
+
+// Expand returns the URL of the link. Templated links are expanded with the given variables,
+// omitted variables are removed from the URL.
+func (l Link) Expand(vars map[string]interface{}) (string, error) {
+	if !l.Templated {
+		return l.Href, nil
+	}
+	return Expand(l.Href, vars)
+}
+
+// URL returns the URL of the link with all template variables removed, e.g.
+// ".../rexReferences/1000/project" for ".../rexReferences/1000/project{?projection}"
+func (l Link) URL() string {
+	u, err := l.Expand(nil)
+	if err != nil {
+		return l.Href
+	}
+	return u
+}
+
+// Variables returns the names of the template variables of the link
+func (l Link) Variables() []string {
+	if !l.Templated {
+		return nil
+	}
+	names, _ := Variables(l.Href)
+	return names
+}
+
+// Links are the links of a resource by their relation. In a document a relation can either have
+// a single link object or an array of links.
+type Links map[string][]Link
+
+// UnmarshalJSON decodes single link objects and arrays of links
+func (l *Links) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*l = make(Links, len(raw))
+	for rel, r := range raw {
+		links, err := unmarshalOneOrMany(r)
+		if err != nil {
+			return fmt.Errorf("Cannot decode link %s: %w", rel, err)
+		}
+		for _, link := range links {
+			var decoded Link
+			if err := json.Unmarshal(link, &decoded); err != nil {
+				return fmt.Errorf("Cannot decode link %s: %w", rel, err)
+			}
+			(*l)[rel] = append((*l)[rel], decoded)
+		}
+	}
+	return nil
+}
+
+// Embedded are the embedded resources by their relation
+type Embedded map[string][]*Resource
+
+// UnmarshalJSON decodes single embedded resources and arrays of embedded resources
+func (e *Embedded) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = make(Embedded, len(raw))
+	for rel, r := range raw {
+		resources, err := unmarshalOneOrMany(r)
+		if err != nil {
+			return fmt.Errorf("Cannot decode embedded %s: %w", rel, err)
+		}
+		(*e)[rel] = make([]*Resource, 0, len(resources))
+		for _, data := range resources {
+			resource, err := Parse(data)
+			if err != nil {
+				return fmt.Errorf("Cannot decode embedded %s: %w", rel, err)
+			}
+			(*e)[rel] = append((*e)[rel], resource)
+		}
+	}
+	return nil
+}
+
+// unmarshalOneOrMany returns the elements of a JSON array or the JSON value itself
+func unmarshalOneOrMany(data json.RawMessage) ([]json.RawMessage, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var many []json.RawMessage
+		err := json.Unmarshal(trimmed, &many)
+		return many, err
+	}
+	return []json.RawMessage{data}, nil
+}
+
+// Resource is a HAL resource. The state of the resource is kept as JSON and decoded by Decode.
+type Resource struct {
+	Links    Links    `json:"_links,omitempty"`
+	Embedded Embedded `json:"_embedded,omitempty"`
+
+	data []byte
+}
+
+// Parse parses the HAL document
+func Parse(data []byte) (*Resource, error) {
+	var r Resource
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// UnmarshalJSON decodes the links and embedded resources and keeps the document for Decode
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	var doc struct {
+		Links    Links    `json:"_links"`
+		Embedded Embedded `json:"_embedded"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	r.Links = doc.Links
+	r.Embedded = doc.Embedded
+	r.data = append([]byte(nil), data...)
+	return nil
+}
+
+// MarshalJSON returns the document the resource has been parsed from
+func (r *Resource) MarshalJSON() ([]byte, error) {
+	if r.data == nil {
+		return []byte("{}"), nil
+	}
+	return r.data, nil
+}
+
+// Decode decodes the state of the resource into v
+func (r *Resource) Decode(v interface{}) error {
+	return json.Unmarshal(r.data, v)
+}
+
+// Link returns the first link with the given relation. The relation can be given as CURIE
+// (e.g. "rex:project") or as the URI the CURIE stands for.
+func (r *Resource) Link(rel string) (Link, bool) {
+	links := r.LinksTo(rel)
+	if len(links) == 0 {
+		return Link{}, false
+	}
+	return links[0], true
+}
+
+// LinksTo returns all links with the given relation
+func (r *Resource) LinksTo(rel string) []Link {
+	rels := make([]string, 0, len(r.Links))
+	for k := range r.Links {
+		rels = append(rels, k)
+	}
+	if k, ok := r.findRel(rel, rels); ok {
+		return r.Links[k]
+	}
+	return nil
+}
+
+// Href returns the URL of the first link with the given relation, expanded with the variables
+func (r *Resource) Href(rel string, vars map[string]interface{}) (string, error) {
+	link, ok := r.Link(rel)
+	if !ok {
+		return "", fmt.Errorf("%w %s", ErrNoLink, rel)
+	}
+	return link.Expand(vars)
+}
+
+// Self returns the URL of the resource without template variables, empty if it has no self link
+func (r *Resource) Self() string {
+	link, _ := r.Link(RelSelf)
+	return link.URL()
+}
+
+// EmbeddedResources returns the embedded resources with the given relation
+func (r *Resource) EmbeddedResources(rel string) []*Resource {
+	rels := make([]string, 0, len(r.Embedded))
+	for k := range r.Embedded {
+		rels = append(rels, k)
+	}
+	if k, ok := r.findRel(rel, rels); ok {
+		return r.Embedded[k]
+	}
+	return nil
+}
+
+// DecodeEmbedded decodes the embedded resources with the given relation into v, which must be a
+// pointer to a slice (e.g. of the projects of a Spring Data REST collection) or, for a single
+// embedded resource, a pointer to a struct. Missing relations leave v unchanged.
+func (r *Resource) DecodeEmbedded(rel string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Cannot decode embedded %s into %T", rel, v)
+	}
+	resources := r.EmbeddedResources(rel)
+	if resources == nil {
+		return nil
+	}
+
+	if rv.Elem().Kind() != reflect.Slice {
+		if len(resources) != 1 {
+			return fmt.Errorf("Cannot decode %d embedded %s into %T", len(resources), rel, v)
+		}
+		return resources[0].Decode(v)
+	}
+
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(resources), len(resources))
+	for i, resource := range resources {
+		if err := resource.Decode(slice.Index(i).Addr().Interface()); err != nil {
+			return fmt.Errorf("Cannot decode embedded %s: %w", rel, err)
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
+
+// ExpandCURIE returns the URI of a relation which is given as CURIE, other relations are
+// returned unchanged
+func (r *Resource) ExpandCURIE(rel string) string {
+	colon := strings.IndexByte(rel, ':')
+	if colon <= 0 {
+		return rel
+	}
+	prefix, reference := rel[:colon], rel[colon+1:]
+	for _, curie := range r.Links[RelCuries] {
+		if curie.Name != prefix {
+			continue
+		}
+		uri, err := Expand(curie.Href, map[string]interface{}{curieRel: reference})
+		if err != nil {
+			return rel
+		}
+		return uri
+	}
+	return rel
+}
+
+// findRel returns the relation of rels which is equal to rel, also if either of them is a CURIE
+func (r *Resource) findRel(rel string, rels []string) (string, bool) {
+	sort.Strings(rels)
+	for _, k := range rels {
+		if k == rel {
+			return k, true
+		}
+	}
+	if _, ok := r.Links[RelCuries]; !ok {
+		return "", false
+	}
+	uri := r.ExpandCURIE(rel)
+	for _, k := range rels {
+		if k != RelCuries && r.ExpandCURIE(k) == uri {
+			return k, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,114 @@
+package hal
+
+import (
+	"errors"
+	"testing"
+)
+
+const projects = `{
+  "_embedded": {
+    "projects": [
+      {
+        "name": "Machine 1",
+        "urn": "robotic-eyes:project:1",
+        "_links": {
+          "self": {"href": "https://rexos/api/v2/projects/1"},
+          "project": {"href": "https://rexos/api/v2/projects/1{?projection}", "templated": true},
+          "rex:rexReferences": {"href": "https://rexos/api/v2/projects/1/rexReferences"}
+        }
+      },
+      {
+        "name": "Machine 2",
+        "urn": "robotic-eyes:project:2",
+        "_links": {"self": {"href": "https://rexos/api/v2/projects/2"}}
+      }
+    ],
+    "owner": {"userId": "alice"}
+  },
+  "_links": {
+    "self": {"href": "https://rexos/api/v2/projects{?page,size,sort}", "templated": true},
+    "rex:search": [
+      {"href": "https://rexos/api/v2/projects/search/findByUrn{?urn}", "templated": true, "name": "findByUrn"},
+      {"href": "https://rexos/api/v2/projects/search/findAllByOwner{?owner}", "templated": true, "name": "findAllByOwner"}
+    ],
+    "curies": [{"name": "rex", "href": "https://docs.rexos/rels/{rel}", "templated": true}]
+  },
+  "page": {"size": 20, "totalElements": 2, "totalPages": 1, "number": 0}
+}`
+
+type project struct {
+	Name string `json:"name"`
+	Urn  string `json:"urn"`
+}
+
+func TestLinks(t *testing.T) {
+	r, err := Parse([]byte(projects))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if self := r.Self(); self != "https://rexos/api/v2/projects" {
+		t.Fatal("Wrong self link", self)
+	}
+	href, err := r.Href(RelSelf, map[string]interface{}{"page": 2, "sort": []string{"name,asc"}})
+	if err != nil || href != "https://rexos/api/v2/projects?page=2&sort=name%2Casc" {
+		t.Fatal("Wrong expanded link", href, err)
+	}
+	if _, err := r.Href("next", nil); !errors.Is(err, ErrNoLink) {
+		t.Fatal("Missing link found", err)
+	}
+
+	// the relation can be given as CURIE or as URI
+	search := r.LinksTo("rex:search")
+	if len(search) != 2 || search[1].Name != "findAllByOwner" {
+		t.Fatal("Wrong links", search)
+	}
+	if link, ok := r.Link("https://docs.rexos/rels/search"); !ok || link.Name != "findByUrn" {
+		t.Fatal("CURIE not resolved", link)
+	}
+	if vars := search[0].Variables(); len(vars) != 1 || vars[0] != "urn" {
+		t.Fatal("Wrong variables", vars)
+	}
+	if uri := r.ExpandCURIE("rex:search"); uri != "https://docs.rexos/rels/search" {
+		t.Fatal("Wrong CURIE", uri)
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	r, err := Parse([]byte(projects))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ps []project
+	if err := r.DecodeEmbedded("projects", &ps); err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || ps[1].Name != "Machine 2" || ps[0].Urn != "robotic-eyes:project:1" {
+		t.Fatal("Wrong projects", ps)
+	}
+
+	embedded := r.EmbeddedResources("projects")
+	if link, _ := embedded[0].Link("project"); link.URL() != "https://rexos/api/v2/projects/1" {
+		t.Fatal("Wrong embedded link", link)
+	}
+
+	var owner struct {
+		UserID string `json:"userId"`
+	}
+	if err := r.DecodeEmbedded("owner", &owner); err != nil || owner.UserID != "alice" {
+		t.Fatal("Wrong owner", owner, err)
+	}
+	if err := r.DecodeEmbedded("projects", &owner); err == nil {
+		t.Fatal("Collection decoded into struct")
+	}
+
+	var page struct {
+		Page struct {
+			TotalElements int `json:"totalElements"`
+		} `json:"page"`
+	}
+	if err := r.Decode(&page); err != nil || page.Page.TotalElements != 2 {
+		t.Fatal("Wrong state", page, err)
+	}
+}
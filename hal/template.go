@@ -0,0 +1,323 @@
+package hal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidTemplate is returned for templates which are not valid URI templates
+var ErrInvalidTemplate = errors.New("Invalid URI template")
+
+// maxPrefix is the largest length of a prefix modifier
+const maxPrefix = 9999
+
+// operator defines the expansion of an expression as specified by RFC 6570, section 3.2
+type operator struct {
+	first         string
+	sep           string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}
+
+var operators = map[byte]operator{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", allowReserved: true},
+	'#': {first: "#", sep: ",", allowReserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// varSpec is a variable of an expression with its modifiers
+type varSpec struct {
+	name    string
+	prefix  int
+	explode bool
+}
+
+// Expand expands the URI template (RFC 6570, up to level 4) with the given variables. Values can
+// be strings, numbers, booleans, slices (lists) or maps (associative arrays), nil values and empty
+// lists and maps are undefined. Undefined variables are omitted together with their operator, so
+// that "projects/1{?projection}" expands to "projects/1" if projection is not given.
+func Expand(template string, vars map[string]interface{}) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(template); {
+		switch c := template[i]; c {
+		case '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("%w: unclosed expression at %d", ErrInvalidTemplate, i)
+			}
+			if err := expandExpression(&b, template[i+1:i+end], vars); err != nil {
+				return "", err
+			}
+			i += end + 1
+		case '}':
+			return "", fmt.Errorf("%w: unopened expression at %d", ErrInvalidTemplate, i)
+		default:
+			// literals are copied, only characters which are not allowed in a URI are encoded
+			// (RFC 6570, section 3.1)
+			end := strings.IndexAny(template[i:], "{}")
+			if end < 0 {
+				end = len(template) - i
+			}
+			b.WriteString(encode(template[i:i+end], true))
+			i += end
+		}
+	}
+	return b.String(), nil
+}
+
+// Variables returns the names of the variables of the template in order of their appearance
+func Variables(template string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(template); {
+		start := strings.IndexByte(template[i:], '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(template[i+start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unclosed expression at %d", ErrInvalidTemplate, i+start)
+		}
+		_, specs, err := parseExpression(template[i+start+1 : i+start+end])
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range specs {
+			if !seen[spec.name] {
+				seen[spec.name] = true
+				names = append(names, spec.name)
+			}
+		}
+		i += start + end + 1
+	}
+	return names, nil
+}
+
+// parseExpression returns the operator and the variables of an expression without braces
+func parseExpression(expression string) (operator, []varSpec, error) {
+	if expression == "" {
+		return operator{}, nil, fmt.Errorf("%w: empty expression", ErrInvalidTemplate)
+	}
+	op, ok := operators[expression[0]]
+	if ok {
+		expression = expression[1:]
+	} else if strings.IndexByte("=,!@|", expression[0]) >= 0 {
+		return operator{}, nil, fmt.Errorf("%w: reserved operator %c", ErrInvalidTemplate, expression[0])
+	} else {
+		op = operators[0]
+	}
+
+	var specs []varSpec
+	for _, s := range strings.Split(expression, ",") {
+		var spec varSpec
+		if strings.HasSuffix(s, "*") {
+			spec.explode = true
+			s = s[:len(s)-1]
+		} else if colon := strings.IndexByte(s, ':'); colon >= 0 {
+			prefix, err := strconv.Atoi(s[colon+1:])
+			if err != nil || prefix <= 0 || prefix > maxPrefix {
+				return operator{}, nil, fmt.Errorf("%w: invalid prefix in %s", ErrInvalidTemplate, s)
+			}
+			spec.prefix = prefix
+			s = s[:colon]
+		}
+		if !validVarName(s) {
+			return operator{}, nil, fmt.Errorf("%w: invalid variable name %q", ErrInvalidTemplate, s)
+		}
+		spec.name = s
+		specs = append(specs, spec)
+	}
+	return op, specs, nil
+}
+
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !isAlphaNum(c) && c != '_' && c != '.' && c != '%' {
+			return false
+		}
+	}
+	return true
+}
+
+func expandExpression(b *strings.Builder, expression string, vars map[string]interface{}) error {
+	op, specs, err := parseExpression(expression)
+	if err != nil {
+		return err
+	}
+
+	first := true
+	for _, spec := range specs {
+		value, err := expandVar(op, spec, vars[spec.name])
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			continue
+		}
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+		b.WriteString(*value)
+	}
+	return nil
+}
+
+// expandVar returns the expansion of a single variable, nil if the variable is undefined
+func expandVar(op operator, spec varSpec, value interface{}) (*string, error) {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	var parts []string
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return expandString(op, spec, string(v.Bytes())), nil
+		}
+		if spec.prefix > 0 {
+			return nil, fmt.Errorf("%w: prefix of list %s", ErrInvalidTemplate, spec.name)
+		}
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := encode(toString(v.Index(i)), op.allowReserved)
+			if spec.explode && op.named {
+				item = namedValue(op, spec.name, item)
+			}
+			parts = append(parts, item)
+		}
+	case reflect.Map:
+		if spec.prefix > 0 {
+			return nil, fmt.Errorf("%w: prefix of map %s", ErrInvalidTemplate, spec.name)
+		}
+		if v.Len() == 0 {
+			return nil, nil
+		}
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]string, v.Len())
+		for _, k := range v.MapKeys() {
+			key := toString(k)
+			keys = append(keys, key)
+			values[key] = toString(v.MapIndex(k))
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key, value := encode(k, op.allowReserved), encode(values[k], op.allowReserved)
+			switch {
+			case !spec.explode:
+				parts = append(parts, key, value)
+			case op.named && value == "":
+				parts = append(parts, key+op.ifEmpty)
+			default:
+				parts = append(parts, key+"="+value)
+			}
+		}
+	default:
+		return expandString(op, spec, toString(v)), nil
+	}
+
+	sep := ","
+	if spec.explode {
+		sep = op.sep
+	}
+	s := strings.Join(parts, sep)
+	if op.named && !spec.explode {
+		s = spec.name + "=" + s
+	}
+	return &s, nil
+}
+
+func expandString(op operator, spec varSpec, value string) *string {
+	if spec.prefix > 0 {
+		if runes := []rune(value); len(runes) > spec.prefix {
+			value = string(runes[:spec.prefix])
+		}
+	}
+	s := encode(value, op.allowReserved)
+	if op.named {
+		s = namedValue(op, spec.name, s)
+	}
+	return &s
+}
+
+func namedValue(op operator, name, value string) string {
+	if value == "" {
+		return name + op.ifEmpty
+	}
+	return name + "=" + value
+}
+
+func toString(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// encode percent-encodes all characters which are not unreserved. If reserved characters are
+// allowed they and existing percent-encoded triplets are kept.
+func encode(s string, allowReserved bool) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			b.WriteByte(c)
+		case allowReserved && isReserved(c):
+			b.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteString(s[i : i+3])
+			i += 2
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
+
+func isAlphaNum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func isUnreserved(c byte) bool {
+	return isAlphaNum(c) || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isReserved(c byte) bool {
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
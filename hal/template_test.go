@@ -0,0 +1,122 @@
+package hal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// rfcVars are the variables of the examples of RFC 6570
+var rfcVars = map[string]interface{}{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	"v":          6,
+	"x":          1024,
+	"y":          768,
+	"empty":      "",
+	"empty_keys": map[string]string{},
+	"undef":      nil,
+}
+
+func TestExpand(t *testing.T) {
+	tests := map[string]string{
+		// level 1 and 2
+		"{var}":             "value",
+		"{hello}":           "Hello%20World%21",
+		"{half}":            "50%25",
+		"O{empty}X":         "OX",
+		"O{undef}X":         "OX",
+		"{+var}":            "value",
+		"{+hello}":          "Hello%20World!",
+		"{+path}/here":      "/foo/bar/here",
+		"here?ref={+path}":  "here?ref=/foo/bar",
+		"X{#var}":           "X#value",
+		"X{#hello}":         "X#Hello%20World!",
+		"map?{x,y}":         "map?1024,768",
+		"{x,hello,y}":       "1024,Hello%20World%21,768",
+		"{+x,hello,y}":      "1024,Hello%20World!,768",
+		"{#path,x}/here":    "#/foo/bar,1024/here",
+		"X{.var}":           "X.value",
+		"X{.x,y}":           "X.1024.768",
+		"{/var}":            "/value",
+		"{/var,x}/here":     "/value/1024/here",
+		"{;x,y}":            ";x=1024;y=768",
+		"{;x,y,empty}":      ";x=1024;y=768;empty",
+		"{?x,y}":            "?x=1024&y=768",
+		"{?x,y,empty}":      "?x=1024&y=768&empty=",
+		"?fixed=yes{&x}":    "?fixed=yes&x=1024",
+		"{&x,y,empty}":      "&x=1024&y=768&empty=",
+		"{var:3}":           "val",
+		"{var:30}":          "value",
+		"{list}":            "red,green,blue",
+		"{list*}":           "red,green,blue",
+		"{keys}":            "comma,%2C,dot,.,semi,%3B",
+		"{keys*}":           "comma=%2C,dot=.,semi=%3B",
+		"{+path:6}/here":    "/foo/b/here",
+		"{+list*}":          "red,green,blue",
+		"{+keys*}":          "comma=,,dot=.,semi=;",
+		"{#keys}":           "#comma,,,dot,.,semi,;",
+		"X{.list}":          "X.red,green,blue",
+		"X{.list*}":         "X.red.green.blue",
+		"X{.empty_keys}":    "X",
+		"{/list*,path:4}":   "/red/green/blue/%2Ffoo",
+		"{;list}":           ";list=red,green,blue",
+		"{;list*}":          ";list=red;list=green;list=blue",
+		"{;keys*}":          ";comma=%2C;dot=.;semi=%3B",
+		"{?list}":           "?list=red,green,blue",
+		"{?list*}":          "?list=red&list=green&list=blue",
+		"{?keys}":           "?keys=comma,%2C,dot,.,semi,%3B",
+		"{&keys*}":          "&comma=%2C&dot=.&semi=%3B",
+		"{count}":           "one,two,three",
+		"{/count*}":         "/one/two/three",
+		"{?dom*}":           "?dom=example&dom=com",
+		"{/dub,who}":        "/me%2Ftoo/fred",
+		"{?undef,var,nope}": "?var=value",
+
+		// REXos links
+		"https://rexos/api/v2/projects/1{?projection}":   "https://rexos/api/v2/projects/1",
+		"https://rexos/api/v2/projects{?page,size,sort}": "https://rexos/api/v2/projects",
+
+		// literals keep pct-encoded triplets and are encoded as UTF-8
+		"https://rexos/search?name=a%20b{&var}": "https://rexos/search?name=a%20b&var=value",
+		"/café/{v}":                             "/caf%C3%A9/6",
+		"/a b/{v}":                              "/a%20b/6",
+	}
+
+	for template, expected := range tests {
+		expanded, err := Expand(template, rfcVars)
+		if err != nil {
+			t.Fatal("Cannot expand", template, err)
+		}
+		if expanded != expected {
+			t.Errorf("Expanded %s to %s, expected %s", template, expanded, expected)
+		}
+	}
+}
+
+func TestExpandInvalid(t *testing.T) {
+	for _, template := range []string{"{var", "var}", "{}", "{=var}", "{var:0}", "{var:10000}", "{va r}", "{list:3}"} {
+		if _, err := Expand(template, rfcVars); !errors.Is(err, ErrInvalidTemplate) {
+			t.Error("Invalid template expanded", template, err)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	names, err := Variables("https://rexos/projects/{id}/users{?page,size,sort*,id}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"id", "page", "size", "sort"}) {
+		t.Fatal("Wrong variables", names)
+	}
+}
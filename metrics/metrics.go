@@ -0,0 +1,310 @@
+// Package metrics provides counters and histograms which are exposed in the Prometheus text
+// format. Metrics are exposed once they are registered at a registry, e.g. at the
+// DefaultRegistry which is served by Handler. The metrics of gococo are prefixed with the
+// Namespace and registered by event.RegisterMetrics and rexos.RegisterMetrics.
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Namespace is the prefix of the names of all metrics of gococo
+const Namespace = "gococo"
+
+// ErrAlreadyRegistered is returned if a metric with the same name is already registered
+var ErrAlreadyRegistered = errors.New("Metric is already registered")
+
+// DefaultBuckets are the upper bounds of the histogram buckets in seconds, if none are given
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector is a metric which can be written in the Prometheus text format
+type Collector interface {
+	Name() string
+	Write(w io.Writer)
+}
+
+// Registry keeps a set of metrics
+type Registry struct {
+	mutex      sync.Mutex
+	collectors map[string]Collector
+}
+
+// DefaultRegistry is the registry which is served by Handler
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Register registers the metrics at the DefaultRegistry, see Registry.Register
+func Register(collectors ...Collector) error {
+	return DefaultRegistry.Register(collectors...)
+}
+
+// Register adds the metrics to the registry. If a metric with the same name has already been
+// registered, an error wrapping ErrAlreadyRegistered is returned and none of the metrics is
+// added.
+func (r *Registry) Register(collectors ...Collector) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	names := make(map[string]bool, len(collectors))
+	for _, c := range collectors {
+		if _, ok := r.collectors[c.Name()]; ok || names[c.Name()] {
+			return fmt.Errorf("%w: %s", ErrAlreadyRegistered, c.Name())
+		}
+		names[c.Name()] = true
+	}
+	for _, c := range collectors {
+		r.collectors[c.Name()] = c
+	}
+	return nil
+}
+
+// Write writes all metrics sorted by name in the Prometheus text format
+func (r *Registry) Write(w io.Writer) {
+	r.mutex.Lock()
+	collectors := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mutex.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].Name() < collectors[j].Name() })
+	for _, c := range collectors {
+		c.Write(w)
+	}
+}
+
+// Handler returns the HTTP handler which serves the metrics of the DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+// GinHandler returns the gin handler which serves the metrics of the DefaultRegistry, e.g.
+// router.GET("/metrics", metrics.GinHandler())
+func GinHandler() gin.HandlerFunc {
+	return gin.WrapH(Handler())
+}
+
+// Handler returns the HTTP handler which serves the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var b bytes.Buffer
+		r.Write(&b)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(b.Bytes())
+	})
+}
+
+// vector keeps the values of a metric per combination of label values
+type vector struct {
+	name   string
+	help   string
+	labels []string
+	mutex  sync.Mutex
+	keys   []string // label values in the order of creation
+	values map[string][]string
+}
+
+func newVector(name, help string, labels []string) vector {
+	return vector{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string][]string),
+	}
+}
+
+// Name returns the name of the metric
+func (v *vector) Name() string {
+	return v.name
+}
+
+// key returns the key of the label values, the caller must hold the lock
+func (v *vector) key(labelValues []string) string {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	if _, ok := v.values[key]; !ok {
+		v.keys = append(v.keys, key)
+		v.values[key] = append([]string(nil), labelValues...)
+	}
+	return key
+}
+
+// sortedKeys returns the keys sorted by label values, the caller must hold the lock
+func (v *vector) sortedKeys() []string {
+	keys := append([]string(nil), v.keys...)
+	sort.Strings(keys)
+	return keys
+}
+
+// writeHeader writes the help and type lines
+func (v *vector) writeHeader(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, kind)
+}
+
+// labelString returns the formatted labels including the optional extra label
+func (v *vector) labelString(labelValues []string, extraName, extraValue string) string {
+	pairs := make([]string, 0, len(labelValues)+1)
+	for i, l := range v.labels {
+		pairs = append(pairs, l+`="`+escapeLabel(labelValues[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+escapeLabel(extraValue)+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a metric which only goes up
+type Counter struct {
+	vector
+	counts map[string]float64
+}
+
+// NewCounter creates a counter with the given labels. It is exposed once it is registered.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{
+		vector: newVector(name, help, labels),
+		counts: make(map[string]float64),
+	}
+}
+
+// Inc increments the counter for the given label values by 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by the given value
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.counts[c.key(labelValues)] += value
+}
+
+// Value returns the current value for the given label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.counts[strings.Join(labelValues, "\xff")]
+}
+
+// Write writes the counter in the Prometheus text format
+func (c *Counter) Write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.writeHeader(w, "counter")
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelString(c.values[key], "", ""), formatFloat(c.counts[key]))
+	}
+}
+
+// Histogram counts observations in buckets, e.g. request durations
+type Histogram struct {
+	vector
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+// histogramSeries are the values of a histogram for a combination of label values
+type histogramSeries struct {
+	counts []uint64 // counts per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram creates a histogram with the given buckets (DefaultBuckets if nil) and labels. It
+// is exposed once it is registered.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &Histogram{
+		vector:  newVector(name, help, labels),
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+// Observe adds the value for the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	key := h.key(labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += value
+}
+
+// Write writes the histogram in the Prometheus text format
+func (h *Histogram) Write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.writeHeader(w, "histogram")
+	for _, key := range h.sortedKeys() {
+		labelValues := h.values[key]
+		s := h.series[key]
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(labelValues, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(labelValues, "", ""), s.count)
+	}
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func escapeHelp(value string) string {
+	return helpEscaper.Replace(value)
+}
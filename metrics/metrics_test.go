@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCounterExposition(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounter("test_total", "Test \\ counter", "code")
+	if err := r.Register(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Inc("200")
+	c.Add(2, "200")
+	c.Inc(`5"00`)
+
+	var b bytes.Buffer
+	r.Write(&b)
+	expected := `# HELP test_total Test \\ counter
+# TYPE test_total counter
+test_total{code="200"} 3
+test_total{code="5\"00"} 1
+`
+	if b.String() != expected {
+		t.Fatal("Wrong exposition", b.String())
+	}
+	if c.Value("200") != 3 {
+		t.Fatal("Wrong value", c.Value("200"))
+	}
+}
+
+func TestHistogramExposition(t *testing.T) {
+	r := NewRegistry()
+	h := NewHistogram("test_seconds", "Test histogram", []float64{1, 0.1}, "verb")
+	if err := r.Register(h); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Observe(0.05, "GET")
+	h.Observe(0.5, "GET")
+	h.Observe(5, "GET")
+
+	var b bytes.Buffer
+	r.Write(&b)
+	for _, line := range []string{
+		`test_seconds_bucket{verb="GET",le="0.1"} 1`,
+		`test_seconds_bucket{verb="GET",le="1"} 2`,
+		`test_seconds_bucket{verb="GET",le="+Inf"} 3`,
+		`test_seconds_sum{verb="GET"} 5.55`,
+		`test_seconds_count{verb="GET"} 3`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Fatal("Missing line", line, b.String())
+		}
+	}
+}
+
+func TestDuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(NewCounter("dup_total", "")); err != nil {
+		t.Fatal(err)
+	}
+	err := r.Register(NewCounter("other_total", ""), NewCounter("dup_total", ""))
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Fatal("Duplicate metric got registered", err)
+	}
+	err = r.Register(NewCounter("new_total", ""), NewCounter("new_total", ""))
+	if !errors.Is(err, ErrAlreadyRegistered) {
+		t.Fatal("Duplicate metric got registered", err)
+	}
+
+	// none of the metrics is registered if one of them is a duplicate
+	var b bytes.Buffer
+	r.Write(&b)
+	if strings.Contains(b.String(), "other_total") || strings.Contains(b.String(), "new_total") {
+		t.Fatal("Metrics partially registered", b.String())
+	}
+}
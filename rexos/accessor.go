@@ -0,0 +1,94 @@
+package rexos
+
+import (
+	"context"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roboticeyes/gococo/status"
+)
+
+// ResourceAccessor reads and modifies HAL resources of REXos
+type ResourceAccessor interface {
+	GetResource(ctx context.Context, resourceName, url string, opts ...CallOption) ([]byte, *status.Status)
+	CreateResource(ctx context.Context, resourceName, url string, r interface{}, opts ...CallOption) ([]byte, *status.Status)
+	PatchResource(ctx context.Context, resourceName, url string, r interface{}, opts ...CallOption) ([]byte, *status.Status)
+	ReplaceResource(ctx context.Context, resourceName, url string, r interface{}, opts ...CallOption) ([]byte, *status.Status)
+	DeleteResource(ctx context.Context, resourceName, url string, opts ...CallOption) *status.Status
+	SendResource(ctx context.Context, resourceName string, r Request, opts ...CallOption) ([]byte, *status.Status)
+
+	GetHalResource(ctx context.Context, resourceName, url string) ([]byte, *status.Status)
+	GetHalResourceNoXF(ctx context.Context, resourceName, url string) ([]byte, *status.Status)
+	GetHalResourceWithServiceUser(ctx context.Context, resourceName, url string) ([]byte, *status.Status)
+	GetHalResourceWithServiceUserNoXF(ctx context.Context, resourceName, url string) ([]byte, *status.Status)
+	CreateHalResource(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	CreateHalResourceWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	CreateHalResourceWithServiceUser(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	CreateHalResourceWithServiceUserWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	PatchHalResource(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	PatchHalResourceWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	PatchHalResourceWithServiceUser(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	PatchHalResourceWithServiceUserWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	ReplaceHalResource(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	ReplaceHalResourceWithServiceUser(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status)
+	DeleteHalResource(ctx context.Context, resourceName, url string) *status.Status
+	LinkHalResources(ctx context.Context, resourceName, associationURL string, links ...string) *status.Status
+	AddHalResourceLinks(ctx context.Context, resourceName, associationURL string, links ...string) *status.Status
+	HalResourceExists(ctx context.Context, resourceName, url string, opts ...CallOption) (bool, *status.Status)
+
+	GetVersionedHalResource(ctx context.Context, resourceName, url string) ([]byte, string, *status.Status)
+	PatchVersionedHalResource(ctx context.Context, resourceName, url, version string, r interface{}) ([]byte, *status.Status)
+	UpdateHalResource(ctx context.Context, resourceName, url string, trials int, mutate MutateFunc) ([]byte, *status.Status)
+}
+
+// FileAccessor transfers the binary files of project files
+type FileAccessor interface {
+	DownloadFileContent(ctx context.Context, downloadURL string, authenticate bool) ([]byte, *status.Status)
+	DownloadFileContentVerified(ctx context.Context, downloadURL string, authenticate bool) ([]byte, *status.Status)
+	DownloadFileStream(ctx context.Context, downloadURL string, authenticate bool) (*FileDownload, *status.Status)
+	DownloadFileStreamVerified(ctx context.Context, downloadURL string, authenticate bool) (*FileDownload, *status.Status)
+	GetFileWithServiceUser(ctx context.Context, c *gin.Context, url string) *status.Status
+	GetProjectFileHash(ctx context.Context, projectFileURL string) (string, *status.Status)
+
+	UploadFileContent(ctx context.Context, uploadURL string, downloadURL string, authenticate bool) *status.Status
+	UploadMultipartFile(ctx context.Context, fileName string, uploadURL string, data io.Reader) *status.Status
+	UploadFile(ctx context.Context, fileName string, uploadURL string, data []byte) *status.Status
+	UploadFileIfChanged(ctx context.Context, fileName string, uploadURL string, data []byte) (bool, *status.Status)
+	UploadMultipart(ctx context.Context, uploadURL string, upload MultipartUpload) *status.Status
+	ResumeUpload(ctx context.Context, session *UploadSession, content io.ReaderAt, progress func(UploadSession)) *status.Status
+}
+
+// ShareAccessor reads and modifies the sharing of projects
+type ShareAccessor interface {
+	GetShare(ctx context.Context, projectResourceURL, userResourceURL, projectUrn string) (Share, *status.Status)
+	UpdateShare(ctx context.Context, projectResourceURL, userResourceURL, projectUrn string, share Share) (Share, *status.Status)
+	CreateOrUpdateUserShare(ctx context.Context, projectResourceURL, userResourceURL, projectUrn string, userShare UserShare) (UserShare, *status.Status)
+	DeleteUserShare(ctx context.Context, resourceURL, projectUrn, userID string) *status.Status
+}
+
+// UserAccessor reads the information of the current user
+type UserAccessor interface {
+	GetUserInformation(ctx context.Context, resourceURL string) (UserInformation, *status.Status)
+	GetCurrentUser(ctx context.Context, resourceURL string) (UserInformation, string, *status.Status)
+	GetUserStatistics(ctx context.Context, resourceURL string) (UserStatistics, *status.Status)
+	GetUserLicenses(ctx context.Context, resourceURL string) (UserLicenses, *status.Status)
+}
+
+// ProjectAccessor performs operations on whole projects
+type ProjectAccessor interface {
+	TransferProject(ctx context.Context, projectResourceURL, userResourceURL string, projectUrn string, newOwner string) (Project, *status.Status)
+	CreateProjectInvitation(ctx context.Context, projectUrn string, projectInvitation ProjectInvitation, projectResourceURL, userResourceURL, invitationURL, rexCodesResourceURL string) (ProjectInvitation, *status.Status)
+}
+
+// RexOSAccessor is the access to REXos which is implemented by Service. Composite services
+// should depend on this interface, or on the role interfaces it consists of, so that REXos can be
+// replaced by the mocks of the rexosmock package in tests.
+type RexOSAccessor interface {
+	ResourceAccessor
+	FileAccessor
+	ShareAccessor
+	UserAccessor
+	ProjectAccessor
+}
+
+var _ RexOSAccessor = (*Service)(nil)
@@ -0,0 +1,219 @@
+package rexos
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/roboticeyes/gococo/event"
+)
+
+// ErrCircuitOpen is returned for calls which are rejected because the circuit breaker of the
+// target is open
+var ErrCircuitOpen = errors.New("Circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests pass
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all requests until the cool-down is over
+	BreakerOpen
+	// BreakerHalfOpen lets a limited number of probe requests pass
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerSettings configures the circuit breakers of a client. One breaker is kept per key,
+// which is the host of the request by default.
+type BreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures which opens the circuit
+	FailureThreshold int
+
+	// CoolDown is the time the circuit stays open before probe requests are let through
+	CoolDown time.Duration
+
+	// HalfOpenRequests is the number of probe requests in the half-open state. The circuit
+	// gets closed again once all of them succeeded.
+	HalfOpenRequests int
+
+	// Key returns the key of the breaker for a request (e.g. host and resource path). If nil,
+	// the host of the request is used.
+	Key func(req *http.Request) string
+}
+
+// DefaultBreakerSettings returns the settings which open the circuit of a host after 5
+// consecutive failures for 10 seconds
+func DefaultBreakerSettings() BreakerSettings {
+	return BreakerSettings{
+		FailureThreshold: 5,
+		CoolDown:         10 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// breakers keeps the circuit breaker per key
+type breakers struct {
+	settings BreakerSettings
+	mutex    sync.Mutex
+	circuits map[string]*breaker
+}
+
+func newBreakers(settings BreakerSettings) *breakers {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 1
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	return &breakers{
+		settings: settings,
+		circuits: make(map[string]*breaker),
+	}
+}
+
+// get returns the breaker for the given request. If breakers are disabled, nil is returned
+// which lets all requests pass.
+func (b *breakers) get(req *http.Request) *breaker {
+	if b == nil {
+		return nil
+	}
+
+	key := req.URL.Host
+	if b.settings.Key != nil {
+		key = b.settings.Key(req)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	cb, ok := b.circuits[key]
+	if !ok {
+		cb = &breaker{key: key, settings: &b.settings}
+		b.circuits[key] = cb
+	}
+	return cb
+}
+
+// breaker is the circuit breaker of a single key
+type breaker struct {
+	key        string
+	settings   *BreakerSettings
+	mutex      sync.Mutex
+	state      BreakerState
+	generation uint64    // incremented on every state change
+	failures   int       // consecutive failures in the closed state
+	probes     int       // pending probe requests in the half-open state
+	passed     int       // successful probe requests in the half-open state
+	openedAt   time.Time // time when the circuit got opened
+}
+
+// allow reports whether a request may be sent. Every allowed request must be followed by a
+// call of done with the returned generation, which identifies the state the request has been
+// admitted in.
+func (cb *breaker) allow() (uint64, bool) {
+	if cb == nil {
+		return 0, true
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if cb.state == BreakerOpen {
+		if time.Since(cb.openedAt) < cb.settings.CoolDown {
+			return cb.generation, false
+		}
+		cb.setState(BreakerHalfOpen)
+	}
+	if cb.state == BreakerHalfOpen {
+		if cb.probes+cb.passed >= cb.settings.HalfOpenRequests {
+			return cb.generation, false
+		}
+		cb.probes++
+	}
+	return cb.generation, true
+}
+
+// done records the result of an allowed request. Requests which got aborted by their own
+// context are neither a success nor a failure of the target and must be passed as ignore.
+// Results of requests which have been admitted before the last state change are ignored, e.g.
+// a slow request of the closed state which finishes while probing.
+func (cb *breaker) done(generation uint64, success, ignore bool) {
+	if cb == nil {
+		return
+	}
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case BreakerClosed:
+		if ignore {
+			return
+		}
+		if success {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		if cb.failures >= cb.settings.FailureThreshold {
+			cb.setState(BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		cb.probes--
+		if ignore {
+			return
+		}
+		if !success {
+			cb.setState(BreakerOpen)
+			return
+		}
+		cb.passed++
+		if cb.passed >= cb.settings.HalfOpenRequests {
+			cb.setState(BreakerClosed)
+		}
+	}
+}
+
+// setState changes the state and resets the counters, the caller must hold the lock
+func (cb *breaker) setState(state BreakerState) {
+	log.WithFields(event.Fields{
+		"circuit": cb.key,
+		"from":    cb.state.String(),
+		"to":      state.String(),
+	}).Warn("Circuit breaker state changed")
+
+	cb.state = state
+	cb.generation++
+	cb.failures = 0
+	cb.probes = 0
+	cb.passed = 0
+	if state == BreakerOpen {
+		cb.openedAt = time.Now()
+	}
+}
+
+// breakerSuccess reports whether the outcome of a request counts as success for the circuit
+// breaker. Transport errors and server errors are failures, client errors are not.
+func breakerSuccess(resp *http.Response, err error) bool {
+	if err != nil {
+		return false
+	}
+	return resp.StatusCode < http.StatusInternalServerError
+}
@@ -0,0 +1,71 @@
+package rexos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var trials int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&trials, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	settings := BreakerSettings{FailureThreshold: 2, CoolDown: 50 * time.Millisecond, HalfOpenRequests: 1}
+	client, _ := NewClientWithOptions(Config{NotApplyServiceUser: true}, WithCircuitBreaker(settings))
+
+	client.Get(testContext(), server.URL, true)
+	client.Get(testContext(), server.URL, true)
+	_, _, code, err := client.Get(testContext(), server.URL, true)
+	if code != http.StatusServiceUnavailable || err == nil {
+		t.Fatal("Circuit not open", code, err)
+	}
+	if n := atomic.LoadInt32(&trials); n != 2 {
+		t.Fatal("Request sent with open circuit", n)
+	}
+
+	// a single probe is sent after the cool-down, which opens the circuit again
+	time.Sleep(60 * time.Millisecond)
+	client.Get(testContext(), server.URL, true)
+	client.Get(testContext(), server.URL, true)
+	if n := atomic.LoadInt32(&trials); n != 3 {
+		t.Fatal("Wrong number of probe requests", n)
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleResults(t *testing.T) {
+	b := newBreakers(BreakerSettings{FailureThreshold: 1, CoolDown: time.Millisecond, HalfOpenRequests: 1})
+	cb := b.get(httptest.NewRequest("GET", "http://rexos/projects", nil))
+
+	// a slow request is admitted while closed, another one opens the circuit
+	slow, _ := cb.allow()
+	failing, _ := cb.allow()
+	cb.done(failing, false, false)
+	if cb.state != BreakerOpen {
+		t.Fatal("Circuit not open", cb.state)
+	}
+
+	// the slow request finishes while the probe is pending
+	time.Sleep(2 * time.Millisecond)
+	probe, ok := cb.allow()
+	if !ok || cb.state != BreakerHalfOpen {
+		t.Fatal("Probe not allowed", cb.state)
+	}
+	cb.done(slow, true, false)
+	if cb.probes != 1 || cb.passed != 0 {
+		t.Fatal("Stale result counted", cb.probes, cb.passed)
+	}
+	if _, ok := cb.allow(); ok {
+		t.Fatal("Second probe allowed")
+	}
+
+	cb.done(probe, true, false)
+	if cb.state != BreakerClosed {
+		t.Fatal("Circuit not closed", cb.state)
+	}
+}
@@ -0,0 +1,242 @@
+package rexos
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheBypassKey is used for disabling the response cache for a single call in the context
+type cacheBypassKey struct{}
+
+// CacheSettings configures the response cache of a client
+type CacheSettings struct {
+	// TTL is the time a cached response is served without asking REXos. Afterwards the response
+	// is revalidated by a conditional GET. A TTL of 0 revalidates every response.
+	TTL time.Duration
+
+	// MaxEntries is the maximum number of cached responses. The least recently used response
+	// is evicted first.
+	MaxEntries int
+
+	// MaxEntryBytes is the maximum body size of a single cached response. Larger responses are
+	// not cached.
+	MaxEntryBytes int64
+
+	// MaxBytes is the maximum body size of all cached responses. The least recently used
+	// responses are evicted first.
+	MaxBytes int64
+}
+
+// DefaultCacheSettings returns the settings which revalidate all responses and keep up to
+// 1000 of them, with at most 1 MiB per response and 64 MiB in total
+func DefaultCacheSettings() CacheSettings {
+	return CacheSettings{
+		MaxEntries:    1000,
+		MaxEntryBytes: 1 << 20,
+		MaxBytes:      64 << 20,
+	}
+}
+
+// ContextWithoutCache returns a new context which makes all REXos calls using this context
+// bypass the response cache
+func ContextWithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// cacheEntry is a cached response
+type cacheEntry struct {
+	key          string
+	url          string
+	header       http.Header
+	body         []byte
+	etag         string
+	lastModified string
+	storedAt     time.Time
+}
+
+// responseCache caches the responses of GET requests. The responses are cached per credential
+// and forwarded host, so that the data of one user never gets served to another user. Only
+// JSON responses (e.g. HAL resources) are cached, file contents are not.
+type responseCache struct {
+	settings CacheSettings
+	mutex    sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	size     int64 // body size of all entries
+}
+
+func newResponseCache(settings CacheSettings) *responseCache {
+	defaults := DefaultCacheSettings()
+	if settings.MaxEntries <= 0 {
+		settings.MaxEntries = defaults.MaxEntries
+	}
+	if settings.MaxEntryBytes <= 0 {
+		settings.MaxEntryBytes = defaults.MaxEntryBytes
+	}
+	if settings.MaxBytes <= 0 {
+		settings.MaxBytes = defaults.MaxBytes
+	}
+	return &responseCache{
+		settings: settings,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// cacheKey returns the key of a GET request. The token is hashed, so that it is not kept in
+// memory longer than necessary. The key includes the accepted media type, as REXos may return
+// another representation of the resource for it.
+func cacheKey(token string, xf XForwarded, query, accept string, authenticate, addXForwardedHeader bool) string {
+	h := sha256.New()
+	if authenticate {
+		h.Write([]byte(token))
+	}
+	h.Write([]byte{0})
+	if addXForwardedHeader {
+		// the forwarded headers change the links of HAL responses
+		h.Write([]byte(xf.Host + "\x00" + xf.Port + "\x00" + xf.Proto))
+	}
+	return hex.EncodeToString(h.Sum(nil)) + " " + accept + " " + query
+}
+
+// lookup returns the cached response for the key. If the cache is disabled for the call, nil
+// is returned.
+func (rc *responseCache) lookup(ctx context.Context, key string) *cacheEntry {
+	if rc == nil {
+		return nil
+	}
+	if bypass, _ := ctx.Value(cacheBypassKey{}).(bool); bypass {
+		return nil
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil
+	}
+	rc.lru.MoveToFront(elem)
+	entry := *elem.Value.(*cacheEntry)
+	return &entry
+}
+
+// fresh returns true if the entry can be served without revalidation
+func (rc *responseCache) fresh(entry *cacheEntry) bool {
+	return rc.settings.TTL > 0 && time.Since(entry.storedAt) < rc.settings.TTL
+}
+
+// setConditions adds the validators of the cached entry to the request
+func (entry *cacheEntry) setConditions(req *http.Request) {
+	if entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+}
+
+// response returns the cached response
+func (entry *cacheEntry) response() *Response {
+	return &Response{StatusCode: http.StatusOK, Header: entry.header, Body: entry.body}
+}
+
+// revalidated marks the entry as fresh after REXos returned 304 (Not Modified)
+func (rc *responseCache) revalidated(entry *cacheEntry) {
+	entry.storedAt = time.Now()
+	rc.put(entry)
+}
+
+// store caches the successful response, if it carries validators or a TTL is set. Responses
+// which are not JSON or are larger than MaxEntryBytes are not cached.
+func (rc *responseCache) store(ctx context.Context, key, url string, resp *http.Response, body []byte) {
+	if rc == nil {
+		return
+	}
+	if bypass, _ := ctx.Value(cacheBypassKey{}).(bool); bypass {
+		return
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Cache-Control") == "no-store" {
+		return
+	}
+	if !cacheableContentType(resp.Header.Get("Content-Type")) {
+		return
+	}
+	if int64(len(body)) > rc.settings.MaxEntryBytes {
+		return
+	}
+
+	entry := &cacheEntry{
+		key:          key,
+		url:          url,
+		header:       resp.Header.Clone(),
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		storedAt:     time.Now(),
+	}
+	if entry.etag == "" && entry.lastModified == "" && rc.settings.TTL <= 0 {
+		return
+	}
+	rc.put(entry)
+}
+
+// cacheableContentType returns true for JSON media types like "application/hal+json"
+func cacheableContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// put inserts or replaces the entry and evicts the least recently used ones
+func (rc *responseCache) put(entry *cacheEntry) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if elem, ok := rc.entries[entry.key]; ok {
+		rc.size += int64(len(entry.body)) - int64(len(elem.Value.(*cacheEntry).body))
+		elem.Value = entry
+		rc.lru.MoveToFront(elem)
+	} else {
+		rc.entries[entry.key] = rc.lru.PushFront(entry)
+		rc.size += int64(len(entry.body))
+	}
+
+	for rc.lru.Len() > rc.settings.MaxEntries || rc.size > rc.settings.MaxBytes {
+		rc.remove(rc.lru.Back())
+	}
+}
+
+// remove removes the element, the caller must hold the lock
+func (rc *responseCache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	rc.lru.Remove(elem)
+	delete(rc.entries, entry.key)
+	rc.size -= int64(len(entry.body))
+}
+
+// invalidate removes the responses of the given URL for all credentials, e.g. after the
+// resource got modified
+func (rc *responseCache) invalidate(url string) {
+	if rc == nil {
+		return
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+	for elem := rc.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*cacheEntry).url == url {
+			rc.remove(elem)
+		}
+		elem = next
+	}
+}
@@ -0,0 +1,124 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseCache(t *testing.T) {
+	requests := 0
+	conditional := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Write([]byte(`{"name":"project"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClientWithOptions(Config{NotApplyServiceUser: true}, WithResponseCache(DefaultCacheSettings()))
+	service := NewServiceWithClient(client)
+
+	for i := 0; i < 2; i++ {
+		body, ret := service.GetHalResource(testContext(), "Project", server.URL)
+		if ret != nil || string(body) != `{"name":"project"}` {
+			t.Fatal("Wrong response", string(body), ret)
+		}
+	}
+	if requests != 2 || conditional != 1 {
+		t.Fatal("Response not revalidated", requests, conditional)
+	}
+
+	// the response of another user must not be revalidated with the cached entry
+	other := context.WithValue(context.Background(), ContextDataKey, ContextData{AccessToken: "Bearer other"})
+	service.GetHalResource(other, "Project", server.URL)
+	if conditional != 1 {
+		t.Fatal("Cached response shared among users")
+	}
+}
+
+func TestResponseCacheLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		switch r.URL.Path {
+		case "/file":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("binary"))
+		case "/chunked":
+			// the response is streamed without Content-Length
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":`))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(`"project"}`))
+		case "/representations":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Content-Type", r.Header.Get("Accept"))
+			w.Write([]byte(`{"accept":"` + r.Header.Get("Accept") + `"}`))
+		case "/large":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"` + strings.Repeat("x", 100) + `"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"name":"project"}`))
+		}
+	}))
+	defer server.Close()
+
+	cache := newResponseCache(CacheSettings{MaxEntryBytes: 64, MaxBytes: 40})
+	client, _ := NewClientWithOptions(Config{NotApplyServiceUser: true})
+	client.cache = cache
+
+	for _, path := range []string{"/file", "/large"} {
+		if _, _, code, err := client.Get(testContext(), server.URL+path, true); err != nil || code != http.StatusOK {
+			t.Fatal("Request failed", path, code, err)
+		}
+	}
+	if cache.lru.Len() != 0 || cache.size != 0 {
+		t.Fatal("Response cached", cache.lru.Len(), cache.size)
+	}
+
+	// chunked responses are cached as well
+	if _, body, _, err := client.Get(testContext(), server.URL+"/chunked", true); err != nil || string(body) != `{"name":"project"}` {
+		t.Fatal("Request failed", string(body), err)
+	}
+	if cache.lru.Len() != 1 || cache.size != 18 {
+		t.Fatal("Chunked response not cached", cache.lru.Len(), cache.size)
+	}
+	cache.invalidate(server.URL + "/chunked")
+
+	// the representations of a resource are cached separately
+	for _, accept := range []string{"application/json", "application/hal+json"} {
+		resp, err := client.Do(testContext(), Request{Method: "GET", URL: server.URL + "/representations", Accept: accept})
+		if err != nil || string(resp.Body) != `{"accept":"`+accept+`"}` {
+			t.Fatal("Wrong representation", accept, string(resp.Body), err)
+		}
+	}
+	cache.invalidate(server.URL + "/representations")
+
+	// the least recently used responses are evicted once the total size is exceeded
+	client.Get(testContext(), server.URL+"/1", true)
+	client.Get(testContext(), server.URL+"/2", true)
+	client.Get(testContext(), server.URL+"/3", true)
+	if cache.lru.Len() != 2 || cache.size != 36 {
+		t.Fatal("Wrong cache size", cache.lru.Len(), cache.size)
+	}
+	if cache.lru.Back().Value.(*cacheEntry).url != server.URL+"/2" {
+		t.Fatal("Wrong entry evicted", cache.lru.Back().Value.(*cacheEntry).url)
+	}
+
+	cache.invalidate(server.URL + "/3")
+	if cache.lru.Len() != 1 || cache.size != 18 {
+		t.Fatal("Wrong cache size", cache.lru.Len(), cache.size)
+	}
+}
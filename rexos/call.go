@@ -0,0 +1,185 @@
+package rexos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/status"
+)
+
+// CallOption configures a single call of the service
+type CallOption func(*callOptions)
+
+// callTimeoutKey marks the context of a call which is limited by WithCallTimeout
+type callTimeoutKey struct{}
+
+// callOptions collects all settings of a single call
+type callOptions struct {
+	serviceUser bool
+	xForwarded  *bool
+	header      http.Header
+	timeout     time.Duration
+	retry       RetryPolicy
+	noCache     bool
+}
+
+// AsServiceUser sends the call with the credentials of the service user instead of the ones of
+// the caller
+func AsServiceUser() CallOption {
+	return func(o *callOptions) {
+		o.serviceUser = true
+	}
+}
+
+// WithXForwarded defines whether the x-forwarded header fields are added. By default they are
+// only added for GET calls.
+func WithXForwarded(enabled bool) CallOption {
+	return func(o *callOptions) {
+		o.xForwarded = &enabled
+	}
+}
+
+// WithHeader adds a header field to the call
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		o.header.Add(key, value)
+	}
+}
+
+// WithCallTimeout limits the duration of the call in addition to the deadline of the context
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithCallRetryPolicy sets the retry policy of the call, see ContextWithRetryPolicy
+func WithCallRetryPolicy(policy RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = policy
+	}
+}
+
+// WithoutCache bypasses the response cache for the call, see ContextWithoutCache
+func WithoutCache() CallOption {
+	return func(o *callOptions) {
+		o.noCache = true
+	}
+}
+
+// newCallOptions applies the options on top of the defaults of the verb
+func newCallOptions(xForwarded bool, opts []CallOption) callOptions {
+	o := callOptions{xForwarded: &xForwarded}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// context returns the context which carries the settings of the call. The returned cancel
+// function must be called after the call.
+func (o *callOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.header != nil {
+		ctx = ContextWithHeaders(ctx, o.header)
+	}
+	if o.retry != nil {
+		ctx = ContextWithRetryPolicy(ctx, o.retry)
+	}
+	if o.noCache {
+		ctx = ContextWithoutCache(ctx)
+	}
+	if o.timeout > 0 {
+		return context.WithTimeout(context.WithValue(ctx, callTimeoutKey{}, o.timeout), o.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// GetResource returns the requested resource. The x-forwarded header fields are added unless
+// disabled by WithXForwarded.
+func (s *Service) GetResource(ctx context.Context, resourceName, url string, opts ...CallOption) ([]byte, *status.Status) {
+	o := newCallOptions(true, opts)
+	return s.call(ctx, resourceName, Request{Method: "GET", URL: url, ContentType: "application/json"}, o, "get")
+}
+
+// CreateResource creates a new resource which needs to be able to write itself to the proper
+// JSON string. In case of success, the body is returned. The x-forwarded header fields are not
+// added unless enabled by WithXForwarded.
+func (s *Service) CreateResource(ctx context.Context, resourceName, url string, r interface{}, opts ...CallOption) ([]byte, *status.Status) {
+	o := newCallOptions(false, opts)
+	return s.call(ctx, resourceName, Request{Method: "POST", URL: url, Payload: encodeJSON(r), ContentType: "application/json"}, o, "create")
+}
+
+// PatchResource sends a partial update to the requested resource. The x-forwarded header
+// fields are not added unless enabled by WithXForwarded.
+func (s *Service) PatchResource(ctx context.Context, resourceName, url string, r interface{}, opts ...CallOption) ([]byte, *status.Status) {
+	o := newCallOptions(false, opts)
+	return s.call(ctx, resourceName, Request{Method: "PATCH", URL: url, Payload: encodeJSON(r), ContentType: "application/json"}, o, "modify")
+}
+
+// ReplaceResource replaces the resource (full update). The x-forwarded header fields are not
+// added unless enabled by WithXForwarded.
+func (s *Service) ReplaceResource(ctx context.Context, resourceName, url string, r interface{}, opts ...CallOption) ([]byte, *status.Status) {
+	o := newCallOptions(false, opts)
+	return s.call(ctx, resourceName, Request{Method: "PUT", URL: url, Payload: encodeJSON(r), ContentType: "application/json"}, o, "replace")
+}
+
+// DeleteResource deletes the resource. The x-forwarded header fields are not added unless
+// enabled by WithXForwarded.
+func (s *Service) DeleteResource(ctx context.Context, resourceName, url string, opts ...CallOption) *status.Status {
+	o := newCallOptions(false, opts)
+	_, ret := s.call(ctx, resourceName, Request{Method: "DELETE", URL: url}, o, "delete")
+	return ret
+}
+
+// SendResource sends a generic request (e.g. with a custom content type) and returns the body
+// in case of success. The credentials and the x-forwarded header fields of the request are
+// overridden by the options.
+func (s *Service) SendResource(ctx context.Context, resourceName string, r Request, opts ...CallOption) ([]byte, *status.Status) {
+	o := newCallOptions(r.XForwarded, opts)
+	o.serviceUser = o.serviceUser || r.ServiceUser
+	return s.call(ctx, resourceName, r, o, "send")
+}
+
+// call performs the request with the given options. Returns the body in case of success. If an
+// error occurred, then the according status is returned. The resourceName and the action are
+// used for the error message
+func (s *Service) call(ctx context.Context, resourceName string, r Request, o callOptions, action string) ([]byte, *status.Status) {
+
+	resp, err := s.send(ctx, resourceName, r, o)
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"resourceName": resourceName,
+			"method":       r.Method,
+			"code":         resp.StatusCode,
+			"url":          r.URL,
+		}).Error("Can not " + action + " HAL resource: " + err.Error())
+		return []byte{}, newRequestStatus(resp.Body, resp.StatusCode, err, "Can not "+action+" resource "+resourceName)
+	}
+	return resp.Body, nil
+}
+
+// send performs the request with the given options and labels it with the resource name
+func (s *Service) send(ctx context.Context, resourceName string, r Request, o callOptions) (*Response, error) {
+
+	ctx, cancel := o.context(contextWithResourceName(ctx, resourceName))
+	defer cancel()
+
+	r.ServiceUser = o.serviceUser
+	r.XForwarded = *o.xForwarded
+	return s.client.Do(ctx, r)
+}
+
+// encodeJSON returns the JSON payload of the resource
+func encodeJSON(r interface{}) io.Reader {
+	b := new(bytes.Buffer)
+	json.NewEncoder(b).Encode(r)
+	return b
+}
@@ -0,0 +1,53 @@
+package rexos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "value" {
+			t.Error("Missing custom header")
+		}
+		if r.Header.Get("X-Forwarded-Host") != "" {
+			t.Error("X-Forwarded header added")
+		}
+		if r.Method == "DELETE" {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	if _, ret := service.GetResource(testContext(), "Project", server.URL, WithXForwarded(false), WithHeader("X-Custom", "value")); ret != nil {
+		t.Fatal("GET failed", ret)
+	}
+	if ret := service.DeleteResource(testContext(), "Project", server.URL, WithHeader("X-Custom", "value")); ret != nil {
+		t.Fatal("DELETE failed", ret)
+	}
+
+	requests := requestsTotal.Value("HEAD", "ExistsTest", "200")
+	if exists, ret := service.HalResourceExists(testContext(), "ExistsTest", server.URL, WithHeader("X-Custom", "value")); !exists || ret != nil {
+		t.Fatal("HEAD failed", exists, ret)
+	}
+	if exists, ret := service.HalResourceExists(testContext(), "ExistsTest", server.URL+"/missing", WithHeader("X-Custom", "value")); exists || ret != nil {
+		t.Fatal("Missing resource exists", exists, ret)
+	}
+	if requestsTotal.Value("HEAD", "ExistsTest", "200") != requests+1 {
+		t.Fatal("Request has not been counted for the resource")
+	}
+
+	// the service user is not initialized
+	_, ret := service.GetResource(testContext(), "Project", server.URL, AsServiceUser())
+	if ret == nil || ret.Code != http.StatusForbidden {
+		t.Fatal("Service user not applied", ret)
+	}
+	if _, ret := service.HalResourceExists(testContext(), "Project", server.URL, AsServiceUser()); ret == nil || ret.Code != http.StatusForbidden {
+		t.Fatal("Service user not applied", ret)
+	}
+}
@@ -0,0 +1,214 @@
+package rexos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/status"
+	"github.com/tidwall/gjson"
+)
+
+// DefaultChunkSize is the chunk size of an upload session if none is given
+const DefaultChunkSize = 8 * 1024 * 1024
+
+// UploadSession is the state of a chunked upload. It can be persisted (e.g. as JSON) after every
+// acknowledged chunk, so that a broken upload can be resumed later on by Service.ResumeUpload.
+type UploadSession struct {
+	// UploadURL is the file link of the project file which receives the chunks
+	UploadURL string `json:"uploadUrl"`
+
+	// ProjectFileURL is the self link of the project file, which is used to verify the checksum
+	ProjectFileURL string `json:"projectFileUrl"`
+
+	// FileName is the name of the uploaded file
+	FileName string `json:"fileName"`
+
+	// Size is the total size of the file
+	Size int64 `json:"size"`
+
+	// ChunkSize is the maximum size of a single chunk
+	ChunkSize int64 `json:"chunkSize"`
+
+	// Offset is the number of bytes which are acknowledged by the server
+	Offset int64 `json:"offset"`
+
+	// ContentHash is the MD5 hash of the whole file as used by the contentHash of REXos, see
+	// ContentHash
+	ContentHash string `json:"contentHash"`
+}
+
+// NewUploadSession creates the session for uploading the given content in chunks. The hash of
+// the content is computed up front. The project file URL is derived from the upload URL, which
+// is expected to be the file link of the project file (e.g. .../projectFiles/1747/file).
+func NewUploadSession(uploadURL, fileName string, content io.ReaderAt, size, chunkSize int64) (*UploadSession, error) {
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	contentHash, err := ContentHash(io.NewSectionReader(content, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadSession{
+		UploadURL:      uploadURL,
+		ProjectFileURL: projectFileURLFromUpload(uploadURL),
+		FileName:       fileName,
+		Size:           size,
+		ChunkSize:      chunkSize,
+		ContentHash:    contentHash,
+	}, nil
+}
+
+// Done returns true if all chunks are acknowledged by the server
+func (u *UploadSession) Done() bool {
+	return u.Offset >= u.Size
+}
+
+// ResumeUpload uploads the remaining chunks of the session, starting at the last acknowledged
+// offset. After every acknowledged chunk the progress function (if given) is called with the
+// updated session, which allows for persisting the state. Once all chunks are uploaded, the
+// contentHash of the project file is compared with the hash of the session.
+//
+// The chunks are posted to the file link of the project file (POST .../projectFiles/{id}/file)
+// as multipart form like UploadFile, with a header like "Content-Range: bytes 0-1023/4096"
+// describing the position of the chunk in the file. The server reports the bytes it has received
+// so far by a "Range: bytes=0-1023" response header, or by a {"range": "bytes=0-1023"} body if
+// the header is missing. The next chunk starts after the acknowledged range, so chunks which
+// have been received partially are sent again from the first missing byte. A gateway which does
+// not support partial uploads replaces the file with every chunk and acknowledges no range. In
+// this case the upload is aborted after the first chunk with 502 (Bad Gateway), unless the chunk
+// covers the whole file. The contentHash is verified in any case.
+func (s *Service) ResumeUpload(ctx context.Context, session *UploadSession, content io.ReaderAt, progress func(UploadSession)) *status.Status {
+
+	for !session.Done() {
+		n := session.ChunkSize
+		if session.Offset+n > session.Size {
+			n = session.Size - session.Offset
+		}
+
+		offset, ret := s.uploadChunk(ctx, session, io.NewSectionReader(content, session.Offset, n), n)
+		if ret != nil {
+			return ret
+		}
+		session.Offset = offset
+
+		if progress != nil {
+			progress(*session)
+		}
+	}
+
+	return s.verifyUpload(ctx, session, content)
+}
+
+// uploadChunk uploads a single chunk and returns the new offset which is acknowledged by the
+// server, see ResumeUpload. A missing range (except for a chunk covering the whole file) or an
+// acknowledged range which is malformed or which does not cover any byte of the chunk is
+// reported as 502 (Bad Gateway), the offset of the session is kept in this case.
+func (s *Service) uploadChunk(ctx context.Context, session *UploadSession, chunk io.Reader, n int64) (int64, *status.Status) {
+
+	header := http.Header{}
+	header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", session.Offset, session.Offset+n-1, session.Size))
+	upload := MultipartUpload{
+		FileName:      session.FileName,
+		Content:       chunk,
+		ContentLength: n,
+	}
+
+	payload, body, contentType := upload.payload()
+	defer body.Close()
+
+	resp, err := s.client.Do(ContextWithHeaders(ctx, header), Request{Method: "POST", URL: session.UploadURL, Payload: payload, ContentType: contentType})
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"uploadUrl": session.UploadURL,
+			"fileName":  session.FileName,
+			"offset":    session.Offset,
+			"code":      resp.StatusCode,
+		}).Error("Can not upload chunk: " + err.Error())
+		return session.Offset, newRequestStatus(resp.Body, resp.StatusCode, err, "Can not upload file "+session.FileName)
+	}
+
+	received := resp.Header.Get("Range")
+	if received == "" {
+		received = gjson.GetBytes(resp.Body, "range").String()
+	}
+	if received == "" {
+		if session.Offset == 0 && n == session.Size {
+			// the whole file has been uploaded at once
+			return n, nil
+		}
+		log.WithContext(ctx).WithFields(event.Fields{
+			"uploadUrl": session.UploadURL,
+			"fileName":  session.FileName,
+			"offset":    session.Offset,
+		}).Error("Can not upload chunk: no range acknowledged, partial uploads are not supported")
+		return session.Offset, status.NewStatus(resp.Body, http.StatusBadGateway, "Can not upload file "+session.FileName+": partial uploads are not supported")
+	}
+
+	offset, ok := parseRangeEnd(received)
+	if !ok || offset <= session.Offset || offset > session.Offset+n {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"uploadUrl": session.UploadURL,
+			"fileName":  session.FileName,
+			"offset":    session.Offset,
+			"range":     received,
+		}).Error("Can not upload chunk: invalid range acknowledged")
+		return session.Offset, status.NewStatus(resp.Body, http.StatusBadGateway, "Can not upload file "+session.FileName+": invalid range "+received)
+	}
+	return offset, nil
+}
+
+// verifyUpload compares the contentHash of the uploaded project file with the one of the session.
+// If REXos reports a hash of another algorithm, the hash of the content is computed again.
+func (s *Service) verifyUpload(ctx context.Context, session *UploadSession, content io.ReaderAt) *status.Status {
+
+	contentHash, ret := s.GetProjectFileHash(ctx, session.ProjectFileURL)
+	if ret != nil {
+		return ret
+	}
+	contentHash = strings.ToLower(contentHash)
+
+	expected := session.ContentHash
+	if len(contentHash) != len(expected) {
+		var err error
+		if expected, err = ContentHashLike(io.NewSectionReader(content, 0, session.Size), contentHash); err != nil {
+			expected = session.ContentHash
+		}
+	}
+
+	if contentHash != expected {
+		err := &IntegrityError{FileName: session.FileName, Expected: expected, Actual: contentHash}
+		log.WithContext(ctx).WithFields(event.Fields{
+			"projectFileUrl": session.ProjectFileURL,
+		}).Error(err.Error())
+		return newRequestStatus(nil, 0, err, "Can not upload file "+session.FileName)
+	}
+	return nil
+}
+
+// parseRangeEnd returns the offset after a received byte range like "bytes=0-1023"
+func parseRangeEnd(value string) (int64, bool) {
+	if !strings.HasPrefix(value, "bytes=") {
+		return 0, false
+	}
+	parts := strings.Split(strings.TrimPrefix(value, "bytes="), "-")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || end < start {
+		return 0, false
+	}
+	return end + 1, true
+}
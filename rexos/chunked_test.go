@@ -0,0 +1,224 @@
+package rexos
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/roboticeyes/gococo/status"
+)
+
+// chunkServer emulates the file link of a project file which accepts partial uploads
+type chunkServer struct {
+	*httptest.Server
+	mutex   sync.Mutex
+	content []byte
+	chunks  []string // Content-Range headers of the received chunks
+
+	// ack returns the Range header for a received chunk, the full range if nil. A negative
+	// status rejects the chunk.
+	ack func(chunk, start, end int) (string, int)
+
+	// corrupt reports a wrong contentHash for the uploaded file
+	corrupt bool
+}
+
+func newChunkServer(size int) *chunkServer {
+	s := &chunkServer{content: make([]byte, size)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *chunkServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/projectFiles/1/file":
+		var start, end, size int
+		if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &size); err != nil || size != len(s.content) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := ioutil.ReadAll(file)
+		s.chunks = append(s.chunks, r.Header.Get("Content-Range"))
+
+		received := fmt.Sprintf("bytes=0-%d", end)
+		if s.ack != nil {
+			var code int
+			if received, code = s.ack(len(s.chunks), start, end); code != 0 {
+				w.WriteHeader(code)
+				return
+			}
+		}
+		// only the acknowledged part of the chunk is stored
+		var last int
+		fmt.Sscanf(received, "bytes=0-%d", &last)
+		if last >= start && last <= end {
+			copy(s.content[start:], data[:last-start+1])
+		}
+		w.Header().Set("Range", received)
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == "GET" && r.URL.Path == "/projectFiles/1":
+		contentHash, _ := ContentHash(bytes.NewReader(s.content))
+		if s.corrupt {
+			contentHash = strings.Repeat("0", len(contentHash))
+		}
+		fmt.Fprintf(w, `{"_links":{"file":{"href":"http://%s/projectFiles/1/file?contentHash=%s"}}}`, r.Host, contentHash)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestResumeUpload(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	server := newChunkServer(len(content))
+	defer server.Close()
+
+	// the connection breaks during the third chunk
+	server.ack = func(chunk, start, end int) (string, int) {
+		if chunk == 3 {
+			return "", http.StatusBadGateway
+		}
+		return fmt.Sprintf("bytes=0-%d", end), 0
+	}
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	session, err := NewUploadSession(server.URL+"/projectFiles/1/file", "scan.rex", bytes.NewReader(content), int64(len(content)), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.ProjectFileURL != server.URL+"/projectFiles/1" {
+		t.Fatal("Wrong project file URL", session.ProjectFileURL)
+	}
+
+	var persisted UploadSession
+	ret := service.ResumeUpload(testContext(), session, bytes.NewReader(content), func(s UploadSession) {
+		persisted = s
+	})
+	if ret == nil || ret.Code != http.StatusBadGateway {
+		t.Fatal("Broken upload not reported", ret)
+	}
+	if persisted.Offset != 16 || persisted.Done() {
+		t.Fatal("Wrong progress", persisted.Offset)
+	}
+
+	// the upload is resumed from the persisted session
+	server.ack = nil
+	if ret := service.ResumeUpload(testContext(), &persisted, bytes.NewReader(content), nil); ret != nil {
+		t.Fatal("Upload not resumed", ret)
+	}
+	expected := []string{"bytes 0-7/20", "bytes 8-15/20", "bytes 16-19/20", "bytes 16-19/20"}
+	if strings.Join(server.chunks, ",") != strings.Join(expected, ",") || !bytes.Equal(server.content, content) {
+		t.Fatal("Wrong upload", server.chunks, string(server.content))
+	}
+}
+
+func TestResumeUploadPartialChunk(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	server := newChunkServer(len(content))
+	defer server.Close()
+
+	// the server acknowledges only the first half of the first chunk
+	server.ack = func(chunk, start, end int) (string, int) {
+		if chunk == 1 {
+			return "bytes=0-3", 0
+		}
+		return fmt.Sprintf("bytes=0-%d", end), 0
+	}
+
+	var offsets []int64
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	session, _ := NewUploadSession(server.URL+"/projectFiles/1/file", "scan.rex", bytes.NewReader(content), int64(len(content)), 10)
+	ret := service.ResumeUpload(testContext(), session, bytes.NewReader(content), func(s UploadSession) {
+		offsets = append(offsets, s.Offset)
+	})
+	if ret != nil {
+		t.Fatal("Upload failed", ret)
+	}
+	if fmt.Sprint(offsets) != "[4 14 20]" {
+		t.Fatal("Wrong offsets", offsets)
+	}
+	if server.chunks[1] != "bytes 4-13/20" || !bytes.Equal(server.content, content) {
+		t.Fatal("Missing bytes not sent again", server.chunks, string(server.content))
+	}
+}
+
+func TestResumeUploadMalformedRange(t *testing.T) {
+	content := []byte("0123456789")
+	for _, received := range []string{"bytes=abc", "0-4", "bytes=0-4-5", "bytes=0-20", "bytes=5-1"} {
+		server := newChunkServer(len(content))
+		server.ack = func(chunk, start, end int) (string, int) {
+			return received, 0
+		}
+
+		service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+		session, _ := NewUploadSession(server.URL+"/projectFiles/1/file", "scan.rex", bytes.NewReader(content), int64(len(content)), 10)
+		ret := service.ResumeUpload(testContext(), session, bytes.NewReader(content), nil)
+		server.Close()
+		if ret == nil || ret.Code != http.StatusBadGateway {
+			t.Fatal("Malformed range accepted", received, ret)
+		}
+		if session.Offset != 0 || len(server.chunks) != 1 {
+			t.Fatal("Wrong progress", received, session.Offset, server.chunks)
+		}
+	}
+}
+
+func TestResumeUploadHashMismatch(t *testing.T) {
+	content := []byte("0123456789")
+	server := newChunkServer(len(content))
+	defer server.Close()
+	server.corrupt = true
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	session, _ := NewUploadSession(server.URL+"/projectFiles/1/file", "scan.rex", bytes.NewReader(content), int64(len(content)), 4)
+	ret := service.ResumeUpload(testContext(), session, bytes.NewReader(content), nil)
+	if !ret.IsIntegrityFailure() || ret.InternalStatus.Type != status.TypeIntegrityFailure {
+		t.Fatal("Hash mismatch not detected", ret)
+	}
+	if !session.Done() {
+		t.Fatal("Chunks not uploaded", session.Offset)
+	}
+}
+
+func TestResumeUploadWithoutPartialUploads(t *testing.T) {
+	// the server ignores the Content-Range header and replaces the file with every chunk
+	var mutex sync.Mutex
+	var posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		posts++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"scan.rex"}`))
+	}))
+	defer server.Close()
+
+	content := []byte("0123456789")
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	session, _ := NewUploadSession(server.URL+"/projectFiles/1/file", "scan.rex", bytes.NewReader(content), int64(len(content)), 4)
+	ret := service.ResumeUpload(testContext(), session, bytes.NewReader(content), nil)
+	if ret == nil || ret.Code != http.StatusBadGateway {
+		t.Fatal("Missing range accepted", ret)
+	}
+	if session.Offset != 0 || posts != 1 {
+		t.Fatal("Upload not aborted after the first chunk", session.Offset, posts)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	b64 "encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -17,6 +18,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/roboticeyes/gococo/cron"
 	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/status"
 )
 
 // AccessTokenType is used for the key of the authorization token for the context
@@ -33,8 +35,11 @@ const (
 	// REXos user id
 	UserIDKey UserIDType = "UserID"
 
-	// MaxTrials defines the maximum trials for sensitive requests to recover any errors
+	// MaxTrials defines the maximum trials of the default retry policy to recover any errors
 	MaxTrials = 3
+
+	// TokenRefreshTimeout is the deadline for requesting a new service user token
+	TokenRefreshTimeout = time.Second * 10
 )
 
 // JwtToken is the token which is returned from REXos
@@ -49,35 +54,99 @@ type JwtToken struct {
 	Jti             string      `json:"jti"`
 }
 
+// FileDownload is the body of a streamed download together with its meta data. The download
+// must be closed after reading.
+type FileDownload struct {
+	io.ReadCloser
+
+	// FileName is taken from the Content-Disposition header, empty if not available
+	FileName string
+
+	// ContentLength is the size of the content, -1 if unknown
+	ContentLength int64
+
+	// ContentType is the media type of the content
+	ContentType string
+}
+
 // Client is the client which is used to send requests to the REXos. The client
 // should be created once and shared among all services.
 type Client struct {
 	httpClient   *http.Client
+	retry        RetryPolicy    // default policy for all calls, see ContextWithRetryPolicy
+	breakers     *breakers      // circuit breakers per host, nil if disabled
+	cache        *responseCache // cache for GET responses, nil if disabled
+	limiter      *rateLimiter   // outbound rate limits per credential, nil if disabled
+	flights      flightGroup    // concurrent GET requests, see flightGroup
 	config       Config
 	serviceToken JwtToken   // this is the service user token which gets updated using a cron job
 	mutex        sync.Mutex // used for accessing the token in parallel
 }
 
-// NewClient create a new REXos HTTP client
+// NewClient create a new REXos HTTP client which uses http.DefaultClient
 func NewClient(cfg Config) *Client {
+	client, _ := NewClientWithOptions(cfg)
+	return client
+}
+
+// NewClientWithOptions creates a new REXos HTTP client which is configured by the given options.
+// Options which affect the transport make the client use its own HTTP client, otherwise
+// http.DefaultClient is used. An error is returned if any of the options cannot be applied
+// (e.g. invalid CA bundle).
+func NewClientWithOptions(cfg Config, opts ...ClientOption) (*Client, error) {
+	o, err := applyOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+
 	client := &Client{
-		httpClient: http.DefaultClient,
+		httpClient: o.httpClient(),
+		retry:      o.retry,
+		breakers:   o.breakers,
+		cache:      o.cache,
+		limiter:    o.limiter,
 		config:     cfg,
 	}
 
 	if !client.config.NotApplyServiceUser {
 		go client.scheduleTokenRefreshHandler()
 	}
-	return client
+	return client, nil
+}
+
+// HTTPClient returns the underlying HTTP client, e.g. for sharing the transport with an Interceptor
+func (c *Client) HTTPClient() *http.Client {
+	return c.httpClient
 }
 
 func (c *Client) refreshToken() bool {
+	success := c.requestToken()
+	observeTokenRefresh(success)
+	return success
+}
+
+// isServiceToken returns true if the authorization is the one of the service user
+func (c *Client) isServiceToken(authorization string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.serviceToken.AccessToken != "" && authorization == "Bearer "+c.serviceToken.AccessToken
+}
+
+// requestToken requests a new service user token and stores it
+func (c *Client) requestToken() bool {
 
 	log.Info("Refreshing service user token ...")
 
 	payload := c.config.ClientID + ":" + c.config.ClientSecret
 	encodedToken := b64.StdEncoding.EncodeToString([]byte(payload))
-	req, _ := http.NewRequest("POST", c.config.AccessTokenURL, bytes.NewReader([]byte(`grant_type=client_credentials`)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), TokenRefreshTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "POST", c.config.AccessTokenURL, bytes.NewReader([]byte(`grant_type=client_credentials`)))
+	if err != nil {
+		log.Error("Service user authentication: cannot create request -", err)
+		return false
+	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Authorization", "Basic "+encodedToken)
@@ -89,12 +158,7 @@ func (c *Client) refreshToken() bool {
 		return false
 	}
 
-	// this is required to properly empty the buffer for the next call
-	defer func() {
-		io.Copy(ioutil.Discard, resp.Body)
-	}()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := readBody(resp)
 
 	if err != nil {
 		log.Error("Service user authentication: cannot get body for authentication -", err)
@@ -147,7 +211,7 @@ func (c *Client) GetWithServiceUser(ctx context.Context, query string, authentic
 	c.mutex.Lock()
 	token := "Bearer " + c.serviceToken.AccessToken
 	c.mutex.Unlock()
-	return c.get(token, xf, query, authenticate, true)
+	return c.get(ctx, token, xf, query, authenticate, true)
 }
 
 // Get performs the GET request with the credentials of the client user (stored in the token)
@@ -162,7 +226,7 @@ func (c *Client) Get(ctx context.Context, query string, authenticate bool) (stri
 	if err != nil {
 		return "", nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
-	return c.get(token, xf, query, authenticate, true)
+	return c.get(ctx, token, xf, query, authenticate, true)
 }
 
 // GetWithServiceUserNoXF performs the GET request with the credentials of the service user - no x-forwarded header added
@@ -180,7 +244,7 @@ func (c *Client) GetWithServiceUserNoXF(ctx context.Context, query string, authe
 		return "", nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.get(token, xf, query, authenticate, false)
+	return c.get(ctx, token, xf, query, authenticate, false)
 }
 
 // GetNoXF performs the GET request with the credentials of the client user (stored in the token) - no x-forwarded header added
@@ -196,87 +260,24 @@ func (c *Client) GetNoXF(ctx context.Context, query string, authenticate bool) (
 		return "", nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.get(token, xf, query, authenticate, false)
+	return c.get(ctx, token, xf, query, authenticate, false)
 }
 
 // Get performs a GET request to the given query and returns the body response which is of type JSON.
 // The return values also contain the http status code and a potential error which has occured.
 // The request will be setup as JSON request and also takes out the authentication information from
 // the given context.
-func (c *Client) get(token string, xf XForwarded, query string, authenticate bool, addXForwardedHeader bool) (string, []byte, int, error) {
-
-	req, _ := http.NewRequest("GET", query, nil)
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("X-Requested-With", "XMLHttpRequest")
-	req.Header.Add("X-Forwarded-For", xf.For)
-
-	if addXForwardedHeader {
-		req.Header.Add("X-Forwarded-Host", xf.Host)
-		req.Header.Add("X-Forwarded-Port", xf.Port)
-		req.Header.Add("X-Forwarded-Proto", xf.Proto)
-		req.Header.Add("X-Forwarded-Prefix", c.config.BasePathExtern)
-	}
-
-	if authenticate {
-		req.Header.Add("Authorization", token)
-	}
-
-	var fileName string
-	trials := 0
-	for ; trials < MaxTrials; trials++ {
-		if trials > 0 {
-			log.Debugf("Internal GET %s: trial %d\n", query, trials)
-		}
+func (c *Client) get(ctx context.Context, token string, xf XForwarded, query string, authenticate bool, addXForwardedHeader bool) (string, []byte, int, error) {
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			log.WithFields(event.Fields{
-				"query":        query,
-				"errorMessage": err.Error(),
-			}).Debug("Internal GET request error")
-			return fileName, nil, resp.StatusCode, err
-		}
-
-		// Check for content-disposition to extract optional fileName
-		contentDisposition := resp.Header.Get("Content-Disposition")
-		if contentDisposition != "" {
-			_, params, err := mime.ParseMediaType(contentDisposition)
-			if err == nil {
-				fileName = params["filename"]
-			}
-		}
-
-		if err != nil {
-			log.Error("Internal GET request error: ", err)
-			return fileName, nil, resp.StatusCode, err
-		}
-		// this is required to properly empty the buffer for the next call
-		defer func() {
-			io.Copy(ioutil.Discard, resp.Body)
-		}()
-
-		body, err := ioutil.ReadAll(resp.Body)
-
-		if resp.StatusCode == http.StatusRequestTimeout {
-			// GET request timed out. Retrying..
-			time.Sleep(time.Millisecond * 100)
-			continue
-		}
-
-		// Other error means outside the 2xx range
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			log.WithFields(event.Fields{
-				"body": string(body),
-			}).Debugf("Internal GET request did not return 2xx as expected but returned %d", resp.StatusCode)
-			return fileName, body, resp.StatusCode, fmt.Errorf("Internal GET request failed after %d trials", trials+1)
-		}
+	resp, err := c.send(ctx, token, xf, Request{
+		Method:      "GET",
+		URL:         query,
+		ContentType: "application/json",
+		XForwarded:  addXForwardedHeader,
+		Anonymous:   !authenticate,
+	})
 
-		// success
-		return fileName, body, resp.StatusCode, err
-	}
-
-	return fileName, nil, http.StatusRequestTimeout, fmt.Errorf("Internal GET request failed after %d trials", trials+1)
+	return fileNameFromHeader(resp.Header), resp.Body, resp.StatusCode, err
 }
 
 // PostWithServiceUser performs the POST request with the credentials of the service user
@@ -293,7 +294,7 @@ func (c *Client) PostWithServiceUser(ctx context.Context, query string, payload
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.post(token, xf, query, payload, contentType, false)
+	return c.post(ctx, token, xf, query, payload, contentType, false)
 }
 
 // Post performs the POST request with the credentials of the client user (stored in the token)
@@ -309,7 +310,7 @@ func (c *Client) Post(ctx context.Context, query string, payload io.Reader, cont
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.post(token, xf, query, payload, contentType, false)
+	return c.post(ctx, token, xf, query, payload, contentType, false)
 }
 
 // PostWithXF performs the POST request with the credentials of the client user (stored in the token) - x-forwareded header fields added
@@ -325,7 +326,7 @@ func (c *Client) PostWithXF(ctx context.Context, query string, payload io.Reader
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.post(token, xf, query, payload, contentType, true)
+	return c.post(ctx, token, xf, query, payload, contentType, true)
 }
 
 // PostWithServiceUserWithXF performs the POST request with the credentials of the service user - x-forwareded header fields added
@@ -342,54 +343,25 @@ func (c *Client) PostWithServiceUserWithXF(ctx context.Context, query string, pa
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.post(token, xf, query, payload, contentType, true)
+	return c.post(ctx, token, xf, query, payload, contentType, true)
 }
 
 // Post performs a POST request to the given query, using the given payload as data, and the provided
 // content-type. The content-type is typically 'application/json', but can also be of formdata in case of
 // binary data upload.
-// WARNING: Do NOT implement retries here. POST is not considered a safe nor idempotent HTTP method!
-func (c *Client) post(token string, xf XForwarded, query string, payload io.Reader, contentType string, addXForwardedHeader bool) ([]byte, int, error) {
-
-	req, _ := http.NewRequest("POST", query, payload)
-	req.Header.Add("Content-Type", contentType)
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("X-Requested-With", "XMLHttpRequest")
-	req.Header.Add("Authorization", token)
-	req.Header.Add("X-Forwarded-For", xf.For)
-
-	if addXForwardedHeader {
-		req.Header.Add("X-Forwarded-Host", xf.Host)
-		req.Header.Add("X-Forwarded-Port", xf.Port)
-		req.Header.Add("X-Forwarded-Proto", xf.Proto)
-		req.Header.Add("X-Forwarded-Prefix", c.config.BasePathExtern)
-	}
-
-	resp, err := c.httpClient.Do(req)
+// POST is neither safe nor idempotent, so the default retry policy only sends it again if the
+// connection was refused.
+func (c *Client) post(ctx context.Context, token string, xf XForwarded, query string, payload io.Reader, contentType string, addXForwardedHeader bool) ([]byte, int, error) {
 
-	if err != nil {
-		log.WithFields(event.Fields{
-			"query":        query,
-			"contentType":  contentType,
-			"errorMessage": err.Error(),
-		}).Debug("Internal POST request error")
-		return []byte{}, http.StatusInternalServerError, nil
-	}
-
-	// this is required to properly empty the buffer for the next call
-	defer func() {
-		io.Copy(ioutil.Discard, resp.Body)
-	}()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	resp, err := c.send(ctx, token, xf, Request{Method: "POST", URL: query, Payload: payload, ContentType: contentType, XForwarded: addXForwardedHeader})
 
 	if resp.StatusCode == http.StatusConflict {
 		// Convention: Do not try to query and return existing resource here.
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"query":       query,
 			"contentType": contentType,
 		}).Debug("Resource already exists")
-		return body, resp.StatusCode, nil
+		return resp.Body, resp.StatusCode, nil
 	}
 
 	if resp.StatusCode == http.StatusRequestTimeout {
@@ -397,17 +369,7 @@ func (c *Client) post(token string, xf XForwarded, query string, payload io.Read
 		return []byte{}, http.StatusRequestTimeout, fmt.Errorf("Internal POST request timed out")
 	}
 
-	// Other error means outside the 2xx range
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.WithFields(event.Fields{
-			"body": string(body),
-		}).Debugf("Internal POST request did not return 2xx as expected but returned %d", resp.StatusCode)
-		return body, resp.StatusCode, fmt.Errorf("Internal POST request failed")
-	}
-
-	// success
-	return body, resp.StatusCode, err
-
+	return responseBody(resp), resp.StatusCode, err
 }
 
 // PatchWithServiceUser performs the PATCH request with the credentials of the service user
@@ -425,7 +387,7 @@ func (c *Client) PatchWithServiceUser(ctx context.Context, query string, payload
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.patch(token, xf, query, payload, contentType, false)
+	return c.patch(ctx, token, xf, query, payload, contentType, false)
 }
 
 // Patch performs the PATCH request with the credentials of the client user (stored in the token)
@@ -441,7 +403,7 @@ func (c *Client) Patch(ctx context.Context, query string, payload io.Reader, con
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.patch(token, xf, query, payload, contentType, false)
+	return c.patch(ctx, token, xf, query, payload, contentType, false)
 }
 
 // PatchWithServiceUserWithXF performs the PATCH request with the credentials of the service user
@@ -459,7 +421,7 @@ func (c *Client) PatchWithServiceUserWithXF(ctx context.Context, query string, p
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.patch(token, xf, query, payload, contentType, true)
+	return c.patch(ctx, token, xf, query, payload, contentType, true)
 }
 
 // PatchWithXF performs the PATCH request with the credentials of the client user (stored in the token)
@@ -475,57 +437,24 @@ func (c *Client) PatchWithXF(ctx context.Context, query string, payload io.Reade
 		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 
-	return c.patch(token, xf, query, payload, contentType, true)
+	return c.patch(ctx, token, xf, query, payload, contentType, true)
 }
 
 // Patch performs a PATCH request to the given query, using the given payload as data, and the provided
 // content-type. The content-type is typically 'application/json', but can also be of formdata in case of
 // binary data upload.
-// WARNING: Do NOT implement retries here. PATCH is not considered a safe nor idempotent HTTP method!
-func (c *Client) patch(token string, xf XForwarded, query string, payload io.Reader, contentType string, addXForwardedHeader bool) ([]byte, int, error) {
+// PATCH is neither safe nor idempotent, so the default retry policy only sends it again if the
+// connection was refused.
+func (c *Client) patch(ctx context.Context, token string, xf XForwarded, query string, payload io.Reader, contentType string, addXForwardedHeader bool) ([]byte, int, error) {
 
-	req, _ := http.NewRequest("PATCH", query, payload)
-	req.Header.Add("Content-Type", contentType)
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("X-Requested-With", "XMLHttpRequest")
-	req.Header.Add("Authorization", token)
-	req.Header.Add("X-Forwarded-For", xf.For)
-
-	if addXForwardedHeader {
-		req.Header.Add("X-Forwarded-Host", xf.Host)
-		req.Header.Add("X-Forwarded-Port", xf.Port)
-		req.Header.Add("X-Forwarded-Proto", xf.Proto)
-		req.Header.Add("X-Forwarded-Prefix", c.config.BasePathExtern)
-	}
-
-	resp, err := c.httpClient.Do(req)
-
-	if err != nil {
-		log.Error("Internal PATCH request error: ", err)
-	}
-
-	// this is required to properly empty the buffer for the next call
-	defer func() {
-		io.Copy(ioutil.Discard, resp.Body)
-	}()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	resp, err := c.send(ctx, token, xf, Request{Method: "PATCH", URL: query, Payload: payload, ContentType: contentType, XForwarded: addXForwardedHeader})
 
 	if resp.StatusCode == http.StatusRequestTimeout {
 		// PATCH request timed out.
 		return []byte{}, http.StatusRequestTimeout, fmt.Errorf("Internal PATCH request timed out")
 	}
 
-	// Other error means outside the 2xx range
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.WithFields(event.Fields{
-			"body": string(body),
-		}).Debugf("Internal PATCH request did not return 2xx as expected but returned %d", resp.StatusCode)
-		return body, resp.StatusCode, fmt.Errorf("Internal PATCH request failed")
-	}
-
-	// success
-	return body, resp.StatusCode, err
+	return responseBody(resp), resp.StatusCode, err
 }
 
 // DeleteWithServiceUser performs the DELETE request with the credentials of the service user
@@ -537,7 +466,7 @@ func (c *Client) DeleteWithServiceUser(ctx context.Context, link string) ([]byte
 	c.mutex.Lock()
 	token := "Bearer " + c.serviceToken.AccessToken
 	c.mutex.Unlock()
-	return c.delete(token, link)
+	return c.delete(ctx, token, link)
 }
 
 // Delete performs the DELETE request with the credentials of the client user (stored in the token)
@@ -548,79 +477,330 @@ func (c *Client) Delete(ctx context.Context, link string) ([]byte, int, error) {
 		return nil, http.StatusForbidden, fmt.Errorf("Missing token in context")
 	}
 
-	return c.delete(token, link)
+	return c.delete(ctx, token, link)
 }
 
 // Delete sends a DELETE request to the given link.
-func (c *Client) delete(token, link string) ([]byte, int, error) {
+func (c *Client) delete(ctx context.Context, token, link string) ([]byte, int, error) {
+
+	// the x-forwarded header fields are optional for DELETE requests
+	xf, _ := GetXForwarded(ctx)
+	resp, err := c.send(ctx, token, xf, Request{Method: "DELETE", URL: link})
+	if err != nil {
+		return responseBody(resp), resp.StatusCode, err
+	}
+
+	// success
+	return []byte{}, resp.StatusCode, nil
+}
 
-	req, _ := http.NewRequest("DELETE", link, nil)
+// GetFileWithServiceUser performs the GET request with the credentials of the service user
+func (c *Client) GetFileWithServiceUser(ctx context.Context, context *gin.Context, query string, authenticate bool) (int, error) {
+	if c.config.NotApplyServiceUser {
+		return http.StatusForbidden, fmt.Errorf("No service user initialized")
+	}
+
+	xf, err := GetXForwarded(ctx)
+	if err != nil {
+		return http.StatusForbidden, fmt.Errorf("Cannot get host")
+	}
+	c.mutex.Lock()
+	token := "Bearer " + c.serviceToken.AccessToken
+	c.mutex.Unlock()
+	return c.getFile(ctx, context, token, xf, query, authenticate)
+}
+
+// getFile performs a GET request to the given query and forwards the file from the given url
+// The return values also contain the http status code and a potential error which has occured.
+// The request takes out the authentication information from the given context.
+func (c *Client) getFile(ctx context.Context, context *gin.Context, token string, xf XForwarded, query string, authenticate bool) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, "GET", query, nil)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	req.Header.Add("Content-Type", "application/octet-stream")
+	req.Header.Add("Accept", "application/octet-stream")
 	req.Header.Add("X-Requested-With", "XMLHttpRequest")
-	req.Header.Add("authorization", token)
+	req.Header.Add("X-Forwarded-Host", xf.Host)
+	req.Header.Add("X-Forwarded-Port", xf.Port)
+	req.Header.Add("X-Forwarded-For", xf.For)
+	req.Header.Add("X-Forwarded-Proto", xf.Proto)
+	req.Header.Add("X-Forwarded-Prefix", c.config.BasePathExtern)
 
-	trials := 0
-	for ; trials < MaxTrials; trials++ {
-		if trials > 0 {
-			log.Debugf("DELETE %s: trial %d\n", link, trials)
-		}
+	if authenticate {
+		req.Header.Add("Authorization", token)
+	}
 
-		resp, err := c.httpClient.Do(req)
+	response, _, err := c.do(ctx, req)
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"query":        query,
+			"errorMessage": err.Error(),
+		}).Error("Internal GET request error")
+		code, err := requestError(ctx, err)
+		return code, fmt.Errorf("Internal GET request failed. Forwarded error: %w", err)
+	}
+
+	// Other error means outside the 2xx range
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		readBody(response)
+		log.WithContext(ctx).WithFields(event.Fields{
+			"query":              query,
+			"responseStatusCode": response.StatusCode,
+		}).Errorf("Internal GET request error %d", response.StatusCode)
+		return http.StatusInternalServerError, fmt.Errorf("Internal GET request failed. Status code : %d", response.StatusCode)
+	}
+
+	fileName := fileNameFromResponse(response)
+
+	reader := response.Body
+	contentLength := response.ContentLength
+	contentType := response.Header.Get("Content-Type")
+
+	extraHeaders := map[string]string{
+		"Content-Disposition": `attachment; filename=` + fileName,
+	}
+	context.DataFromReader(http.StatusOK, contentLength, contentType, reader, extraHeaders)
+	response.Body.Close()
+
+	// a broken download can only be detected after the data has been forwarded
+	if ctx.Err() != nil {
+		return contextCode(ctx.Err()), ctx.Err()
+	}
+
+	// success
+	return http.StatusOK, nil
+}
+
+// Request describes a generic REXos call which is sent by Client.Do
+type Request struct {
+	// Method is the HTTP method (e.g. "PUT")
+	Method string
+
+	// URL is the absolute URL of the resource
+	URL string
+
+	// Payload is the optional body of the request
+	Payload io.Reader
+
+	// ContentType is the media type of the payload (e.g. "text/uri-list")
+	ContentType string
+
+	// Accept is the media type of the response, "application/json" if empty
+	Accept string
+
+	// ServiceUser sends the request with the credentials of the service user instead of the
+	// ones of the client user (stored in the token)
+	ServiceUser bool
 
+	// XForwarded adds the x-forwarded header fields
+	XForwarded bool
+
+	// Anonymous sends the request without any credentials
+	Anonymous bool
+}
+
+// Response is the result of a REXos call which is sent by Client.Do
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// PutWithServiceUser performs the PUT request with the credentials of the service user
+func (c *Client) PutWithServiceUser(ctx context.Context, query string, payload io.Reader, contentType string) ([]byte, int, error) {
+	resp, err := c.Do(ctx, Request{Method: "PUT", URL: query, Payload: payload, ContentType: contentType, ServiceUser: true})
+	return resp.Body, resp.StatusCode, err
+}
+
+// Put performs the PUT request with the credentials of the client user (stored in the token)
+func (c *Client) Put(ctx context.Context, query string, payload io.Reader, contentType string) ([]byte, int, error) {
+	resp, err := c.Do(ctx, Request{Method: "PUT", URL: query, Payload: payload, ContentType: contentType})
+	return resp.Body, resp.StatusCode, err
+}
+
+// HeadWithServiceUser performs the HEAD request with the credentials of the service user
+func (c *Client) HeadWithServiceUser(ctx context.Context, query string) (http.Header, int, error) {
+	resp, err := c.Do(ctx, Request{Method: "HEAD", URL: query, ServiceUser: true})
+	return resp.Header, resp.StatusCode, err
+}
+
+// Head performs the HEAD request with the credentials of the client user (stored in the token)
+func (c *Client) Head(ctx context.Context, query string) (http.Header, int, error) {
+	resp, err := c.Do(ctx, Request{Method: "HEAD", URL: query})
+	return resp.Header, resp.StatusCode, err
+}
+
+// Do sends a generic request with the given method and content type. The response is always
+// returned, an error is returned additionally if the request failed or the response is outside
+// the 2xx range. The retry policy decides which methods are sent again.
+func (c *Client) Do(ctx context.Context, r Request) (*Response, error) {
+
+	var token string
+	if r.ServiceUser {
+		if c.config.NotApplyServiceUser {
+			return &Response{StatusCode: http.StatusForbidden}, fmt.Errorf("No service user initialized")
+		}
+		c.mutex.Lock()
+		token = "Bearer " + c.serviceToken.AccessToken
+		c.mutex.Unlock()
+	} else if !r.Anonymous {
+		var err error
+		token, err = GetAccessTokenFromContext(ctx)
 		if err != nil {
-			log.Error("Internal DELETE request error: ", err)
+			return &Response{StatusCode: http.StatusForbidden}, fmt.Errorf("Missing token in context")
 		}
+	}
 
-		// this is required to properly empty the buffer for the next call
-		defer func() {
-			io.Copy(ioutil.Discard, resp.Body)
-		}()
+	xf, err := GetXForwarded(ctx)
+	if err != nil {
+		return &Response{StatusCode: http.StatusForbidden}, fmt.Errorf("Cannot get host")
+	}
 
-		body, err := ioutil.ReadAll(resp.Body)
+	return c.send(ctx, token, xf, r)
+}
 
-		if resp.StatusCode == http.StatusRequestTimeout {
-			// DELETE request timed out. Retrying..
-			time.Sleep(time.Millisecond * 100)
-			continue
-		}
+// send performs the request with the given credentials. GET requests are served from the
+// response cache if possible, concurrent GET requests of the same resource with the same
+// credentials are coalesced into a single request.
+func (c *Client) send(ctx context.Context, token string, xf XForwarded, r Request) (*Response, error) {
+	if r.Method != "GET" || !coalescable(ctx) {
+		return c.sendRequest(ctx, token, xf, r)
+	}
+	key := cacheKey(token, xf, r.URL, r.Accept, !r.Anonymous, r.XForwarded)
+	return c.flights.do(ctx, key, func() (*Response, error) {
+		return c.sendRequest(ctx, token, xf, r)
+	})
+}
+
+// sendRequest performs a single request of send
+func (c *Client) sendRequest(ctx context.Context, token string, xf XForwarded, r Request) (*Response, error) {
+
+	req, err := newRequestWithPayload(ctx, r.Method, r.URL, r.Payload)
+	if err != nil {
+		return &Response{StatusCode: http.StatusBadRequest}, err
+	}
+	if r.ContentType != "" {
+		req.Header.Add("Content-Type", r.ContentType)
+	}
+	accept := r.Accept
+	if accept == "" {
+		accept = "application/json"
+	}
+	req.Header.Add("Accept", accept)
+	req.Header.Add("X-Requested-With", "XMLHttpRequest")
+	req.Header.Add("X-Forwarded-For", xf.For)
+
+	if r.XForwarded {
+		req.Header.Add("X-Forwarded-Host", xf.Host)
+		req.Header.Add("X-Forwarded-Port", xf.Port)
+		req.Header.Add("X-Forwarded-Proto", xf.Proto)
+		req.Header.Add("X-Forwarded-Prefix", c.config.BasePathExtern)
+	}
 
-		// Other error means outside the 2xx range
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			log.WithFields(event.Fields{
-				"body": string(body),
-			}).Debugf("Internal DELETE request did not return 2xx as expected but returned %d", resp.StatusCode)
-			return body, resp.StatusCode, fmt.Errorf("Internal DELETE request failed after %d trials", trials+1)
+	if !r.Anonymous {
+		req.Header.Add("Authorization", token)
+	}
+
+	var key string
+	var cached *cacheEntry
+	if r.Method == "GET" {
+		key = cacheKey(token, xf, r.URL, r.Accept, !r.Anonymous, r.XForwarded)
+		cached = c.cache.lookup(ctx, key)
+	}
+	if cached != nil {
+		if c.cache.fresh(cached) {
+			return cached.response(), nil
 		}
+		cached.setConditions(req)
+	}
+
+	resp, trials, err := c.do(ctx, req)
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"method":       r.Method,
+			"query":        r.URL,
+			"trials":       trials,
+			"errorMessage": err.Error(),
+		}).Debugf("Internal %s request error", r.Method)
+		code, err := requestError(ctx, err)
+		return &Response{StatusCode: code}, err
+	}
 
-		// success
-		return []byte{}, resp.StatusCode, err
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		readBody(resp)
+		c.cache.revalidated(cached)
+		return cached.response(), nil
 	}
 
-	return []byte{}, http.StatusRequestTimeout, fmt.Errorf("Internal DELETE request failed after %d trials", trials+1)
+	body, err := readBody(resp)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Internal %s request error: %v", r.Method, err)
+		code, err := requestError(ctx, err)
+		return &Response{StatusCode: code, Header: resp.Header}, err
+	}
+	response := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+
+	// Other error means outside the 2xx range
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"body": string(body),
+		}).Debugf("Internal %s request did not return 2xx as expected but returned %d", r.Method, resp.StatusCode)
+		return response, fmt.Errorf("Internal %s request failed after %d trials", r.Method, trials)
+	}
+
+	// success
+	switch r.Method {
+	case "GET":
+		c.cache.store(ctx, key, r.URL, resp, body)
+	case "HEAD":
+	default:
+		c.cache.invalidate(r.URL)
+	}
+	return response, nil
 }
 
-// GetFileWithServiceUser performs the GET request with the credentials of the service user
-func (c *Client) GetFileWithServiceUser(ctx context.Context, context *gin.Context, query string, authenticate bool) (int, error) {
+// GetStreamWithServiceUser performs the streaming GET request with the credentials of the service user
+func (c *Client) GetStreamWithServiceUser(ctx context.Context, query string, authenticate bool) (*FileDownload, int, error) {
 	if c.config.NotApplyServiceUser {
-		return http.StatusForbidden, fmt.Errorf("No service user initialized")
+		return nil, http.StatusForbidden, fmt.Errorf("No service user initialized")
 	}
 
 	xf, err := GetXForwarded(ctx)
 	if err != nil {
-		return http.StatusForbidden, fmt.Errorf("Cannot get host")
+		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
 	}
 	c.mutex.Lock()
 	token := "Bearer " + c.serviceToken.AccessToken
 	c.mutex.Unlock()
-	return c.getFile(context, token, xf, query, authenticate)
+	return c.getStream(ctx, token, xf, query, authenticate)
 }
 
-// getFile performs a GET request to the given query and forwards the file from the given url
-// The return values also contain the http status code and a potential error which has occured.
-// The request takes out the authentication information from the given context.
-func (c *Client) getFile(context *gin.Context, token string, xf XForwarded, query string, authenticate bool) (int, error) {
+// GetStream performs the streaming GET request with the credentials of the client user (stored in the token)
+func (c *Client) GetStream(ctx context.Context, query string, authenticate bool) (*FileDownload, int, error) {
 
-	req, _ := http.NewRequest("GET", query, nil)
-	req.Header.Add("Content-Type", "application/octet-stream")
+	token, err := GetAccessTokenFromContext(ctx)
+	if err != nil {
+		return nil, http.StatusForbidden, fmt.Errorf("Missing token in context")
+	}
+
+	xf, err := GetXForwarded(ctx)
+	if err != nil {
+		return nil, http.StatusForbidden, fmt.Errorf("Cannot get host")
+	}
+	return c.getStream(ctx, token, xf, query, authenticate)
+}
+
+// getStream performs a GET request to the given query and returns the response body without
+// reading it. The caller must close the returned download. Reading the body is still bound to
+// the context, so the deadline of the context must allow for the whole transfer.
+func (c *Client) getStream(ctx context.Context, token string, xf XForwarded, query string, authenticate bool) (*FileDownload, int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, "GET", query, nil)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
 	req.Header.Add("Accept", "application/octet-stream")
 	req.Header.Add("X-Requested-With", "XMLHttpRequest")
 	req.Header.Add("X-Forwarded-Host", xf.Host)
@@ -633,69 +813,207 @@ func (c *Client) getFile(context *gin.Context, token string, xf XForwarded, quer
 		req.Header.Add("Authorization", token)
 	}
 
-	var fileName string
-	trials := 0
-	for ; trials < MaxTrials; trials++ {
-		if trials > 0 {
-			log.Debugf("Internal GET %s: trial %d\n", query, trials)
-		}
+	resp, trials, err := c.do(ctx, req)
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"query":        query,
+			"trials":       trials,
+			"errorMessage": err.Error(),
+		}).Debug("Internal GET request error")
+		code, err := requestError(ctx, err)
+		return nil, code, err
+	}
 
-		response, err := c.httpClient.Do(req)
+	// Other error means outside the 2xx range
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := readBody(resp)
+		log.WithContext(ctx).WithFields(event.Fields{
+			"body": string(body),
+		}).Debugf("Internal GET request did not return 2xx as expected but returned %d", resp.StatusCode)
+		return nil, resp.StatusCode, fmt.Errorf("Internal GET request failed after %d trials", trials)
+	}
 
-		// this is required to properly empty the buffer for the next call
-		defer func() {
-			io.Copy(ioutil.Discard, response.Body)
-		}()
-		if err != nil {
-			log.WithFields(event.Fields{
-				"query":        query,
-				"errorMessage": err.Error(),
-			}).Debug("Internal GET request error")
+	download := &FileDownload{
+		ReadCloser:    resp.Body,
+		FileName:      fileNameFromResponse(resp),
+		ContentLength: resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+	}
+	return download, resp.StatusCode, nil
+}
+
+// do sends the request and retries it according to the retry policy of the call. The returned
+// response is the one of the last trial, its body must be closed by the caller. Besides the
+// response the number of trials is returned. Request bodies are replayed by http.Request.GetBody,
+// requests whose body cannot be replayed are sent only once. If the circuit breaker of the target
+// is open, no request is sent and ErrCircuitOpen is returned.
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, int, error) {
+	setRequestID(ctx, req)
+	span := startSpan(ctx, req)
+	start := time.Now()
+	resp, trials, err := c.doWithRetries(ctx, req)
+	observeRequest(ctx, req.Method, resp, trials, err, time.Since(start))
+	finishSpan(span, resp, trials, err)
+	return resp, trials, err
+}
+
+// doWithRetries performs the trials of do
+func (c *Client) doWithRetries(ctx context.Context, req *http.Request) (*http.Response, int, error) {
+
+	policy := c.retryPolicy(ctx)
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	circuit := c.breakers.get(req)
+	setContextHeaders(ctx, req)
+
+	for trial := 1; ; trial++ {
+		if err := c.limiter.wait(ctx, c.isServiceToken(req.Header.Get("Authorization")), rateLimitKey(req)); err != nil {
+			log.WithContext(ctx).WithFields(event.Fields{
+				"method": req.Method,
+				"query":  req.URL.String(),
+			}).Debug("Internal request rejected by rate limiter")
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, trial, err
 		}
-		if response.StatusCode == http.StatusRequestTimeout {
-			// GET request timed out. Retrying..
-			time.Sleep(time.Millisecond * 100)
-			continue
+		generation, allowed := circuit.allow()
+		if !allowed {
+			log.WithContext(ctx).WithFields(event.Fields{
+				"method": req.Method,
+				"query":  req.URL.String(),
+			}).Debug("Internal request rejected by circuit breaker")
+			if req.Body != nil {
+				// the transport closes the body otherwise, which stops streaming producers
+				req.Body.Close()
+			}
+			return nil, trial, fmt.Errorf("%w for %s", ErrCircuitOpen, req.URL.Host)
 		}
 
-		// Other error means outside the 2xx range
-		if response.StatusCode < 200 || response.StatusCode >= 300 {
-			log.WithFields(event.Fields{
-				"query":              query,
-				"responseStatusCode": response.StatusCode,
-			}).Errorf("Internal GET request error %d", response.StatusCode)
-			return http.StatusInternalServerError, fmt.Errorf("Internal GET request failed. Status code : %d", response.StatusCode)
+		resp, err := c.httpClient.Do(req)
+		circuit.done(generation, breakerSuccess(resp, err), ctx.Err() != nil)
+		if ctx.Err() != nil || !replayable {
+			captureHeader(ctx, resp)
+			return resp, trial, err
 		}
-		if err != nil {
-			log.WithFields(event.Fields{
-				"query":              query,
-				"responseStatusCode": response.StatusCode,
-				"error":              err.Error(),
-			}).Error("Internal GET request error", err.Error())
-			return http.StatusInternalServerError, fmt.Errorf("Internal GET request failed. Forwarded error: " + err.Error())
+
+		delay, retry := policy.Retry(trial, req, resp, err)
+		if !retry {
+			captureHeader(ctx, resp)
+			return resp, trial, err
+		}
+		if resp != nil {
+			// drain the body, so that the connection can be reused
+			readBody(resp)
+		}
+
+		log.WithContext(ctx).WithFields(event.Fields{
+			"method": req.Method,
+			"query":  req.URL.String(),
+			"trial":  trial,
+			"delay":  delay.String(),
+		}).Debug("Retrying internal request")
+		if err := sleep(ctx, delay); err != nil {
+			return nil, trial, err
 		}
 
-		// Check for content-disposition to extract optional fileName
-		contentDisposition := response.Header.Get("Content-Disposition")
-		if contentDisposition != "" {
-			_, params, err := mime.ParseMediaType(contentDisposition)
-			if err == nil {
-				fileName = params["filename"]
+		req = req.Clone(ctx)
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, trial, err
 			}
 		}
+	}
+}
 
-		reader := response.Body
-		contentLength := response.ContentLength
-		contentType := response.Header.Get("Content-Type")
+// sizedReader is a payload whose length is known in advance, although it cannot be determined
+// by http.NewRequest (e.g. a streamed multipart body)
+type sizedReader struct {
+	io.Reader
+	size int64
+}
 
-		extraHeaders := map[string]string{
-			"Content-Disposition": `attachment; filename=` + fileName,
+// newRequestWithPayload creates a new request with the given payload. The content length is
+// set for payloads with a known size, other readers are sent with chunked encoding.
+func newRequestWithPayload(ctx context.Context, method, query string, payload io.Reader) (*http.Request, error) {
+	if sr, ok := payload.(*sizedReader); ok {
+		req, err := http.NewRequestWithContext(ctx, method, query, sr.Reader)
+		if err != nil {
+			return nil, err
 		}
-		context.DataFromReader(http.StatusOK, contentLength, contentType, reader, extraHeaders)
+		req.ContentLength = sr.size
+		return req, nil
+	}
+	return http.NewRequestWithContext(ctx, method, query, payload)
+}
+
+// fileNameFromResponse returns the optional file name of the Content-Disposition header
+func fileNameFromResponse(resp *http.Response) string {
+	return fileNameFromHeader(resp.Header)
+}
 
-		// success
-		return http.StatusOK, nil
+// fileNameFromHeader returns the optional file name of the Content-Disposition header
+func fileNameFromHeader(header http.Header) string {
+	contentDisposition := header.Get("Content-Disposition")
+	if contentDisposition == "" {
+		return ""
 	}
+	_, params, err := mime.ParseMediaType(contentDisposition)
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+// responseBody returns the body of the response, an empty body if the request failed before a
+// response has been received
+func responseBody(resp *Response) []byte {
+	if resp.Body == nil {
+		return []byte{}
+	}
+	return resp.Body
+}
+
+// readBody reads the full body of the response and closes it afterwards, so that the
+// connection can be reused for the next call
+func readBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// sleep waits for the given duration. It returns early with the context error if the
+// context gets canceled or its deadline is exceeded in the meantime.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	return http.StatusRequestTimeout, fmt.Errorf("Internal GET request failed after %d trials", trials+1)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// requestError returns the status code and the error for a request which could not be
+// performed. If the request got aborted by its context, the context error is returned.
+// Requests rejected by the circuit breaker are reported as 503 (Service Unavailable).
+func requestError(ctx context.Context, err error) (int, error) {
+	if ctx.Err() != nil {
+		return contextCode(ctx.Err()), ctx.Err()
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return http.StatusServiceUnavailable, err
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return http.StatusTooManyRequests, err
+	}
+	return http.StatusInternalServerError, err
+}
+
+// contextCode returns the status code which is reported for a context error
+func contextCode(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return status.StatusClientClosedRequest
 }
@@ -0,0 +1,224 @@
+package rexos
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roboticeyes/gococo/status"
+)
+
+// blockingServer returns a server which answers after the request got aborted by the client.
+// The started channel receives a value as soon as a request has been read.
+func blockingServer() (*httptest.Server, chan struct{}) {
+	started := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	return server, started
+}
+
+func TestGetDeadlineExceeded(t *testing.T) {
+	server, _ := blockingServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(testContext(), 50*time.Millisecond)
+	defer cancel()
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+
+	start := time.Now()
+	_, ret := service.GetResource(ctx, "Project", server.URL)
+	if !ret.IsDeadlineExceeded() || ret.Code != http.StatusGatewayTimeout {
+		t.Fatal("Wrong status", ret)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("Request not aborted", time.Since(start))
+	}
+}
+
+func TestPostCanceled(t *testing.T) {
+	server, started := blockingServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(testContext())
+	defer cancel()
+	go func() {
+		<-started
+		cancel()
+	}()
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+
+	_, ret := service.CreateResource(ctx, "Project", server.URL, Project{Name: "Machine 1"})
+	if ret == nil || ret.InternalStatus.Type != status.TypeRequestCanceled || ret.Code != status.StatusClientClosedRequest {
+		t.Fatal("Wrong status", ret)
+	}
+
+	// the legacy verbs report the context error as well
+	ctx, cancel = context.WithCancel(testContext())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, code, err := service.client.Post(ctx, server.URL, nil, "application/json")
+	if !errors.Is(err, context.Canceled) || code != status.StatusClientClosedRequest {
+		t.Fatal("Wrong error", code, err)
+	}
+}
+
+func TestDownloadStreamCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(testContext())
+	defer cancel()
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+
+	download, ret := service.DownloadFileStream(ctx, server.URL, true)
+	if ret != nil {
+		t.Fatal("Download failed", ret)
+	}
+	defer download.Close()
+
+	buf := make([]byte, len("first chunk"))
+	if n, err := download.Read(buf); err != nil || string(buf[:n]) != "first chunk" {
+		t.Fatal("Wrong content", string(buf[:n]), err)
+	}
+
+	// canceling the context aborts the transfer instead of waiting for the server
+	cancel()
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(download)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatal("Wrong error", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Transfer not aborted")
+	}
+
+	// a deadline which is exceeded before the response arrives is reported by the status
+	blocking, _ := blockingServer()
+	defer blocking.Close()
+	ctx, cancel = context.WithTimeout(testContext(), 50*time.Millisecond)
+	defer cancel()
+	if _, ret := service.DownloadFileStream(ctx, blocking.URL, true); !ret.IsDeadlineExceeded() {
+		t.Fatal("Wrong status", ret)
+	}
+}
+
+func TestDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Echo-Authorization", r.Header.Get("Authorization"))
+		w.Header().Set("X-Echo-Host", r.Header.Get("X-Forwarded-Host"))
+		switch {
+		case r.Method == "PUT" && r.Header.Get("Content-Type") == "text/uri-list" && string(body) == "https://rexos/projects/1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "GET" && r.Header.Get("Accept") == "application/hal+json":
+			w.Write([]byte(`{"name":"Machine 1"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"unexpected request"}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), ContextDataKey, ContextData{
+		AccessToken: "Bearer test",
+		XForwarded:  XForwarded{Host: "rexos.example.com"},
+	})
+	client := NewClient(Config{NotApplyServiceUser: true})
+
+	resp, err := client.Do(ctx, Request{Method: "PUT", URL: server.URL, Payload: strings.NewReader("https://rexos/projects/1"), ContentType: "text/uri-list"})
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatal("PUT failed", resp.StatusCode, err)
+	}
+	if resp.Header.Get("X-Echo-Authorization") != "Bearer test" || resp.Header.Get("X-Echo-Host") != "" {
+		t.Fatal("Wrong request header", resp.Header)
+	}
+
+	resp, err = client.Do(ctx, Request{Method: "GET", URL: server.URL, Accept: "application/hal+json", XForwarded: true, Anonymous: true})
+	if err != nil || string(resp.Body) != `{"name":"Machine 1"}` {
+		t.Fatal("GET failed", string(resp.Body), err)
+	}
+	if resp.Header.Get("X-Echo-Authorization") != "" || resp.Header.Get("X-Echo-Host") != "rexos.example.com" {
+		t.Fatal("Wrong request header", resp.Header)
+	}
+
+	// the response is returned for status codes outside the 2xx range as well
+	resp, err = client.Do(ctx, Request{Method: "DELETE", URL: server.URL})
+	if err == nil || resp.StatusCode != http.StatusBadRequest || string(resp.Body) != `{"message":"unexpected request"}` {
+		t.Fatal("Wrong response", resp.StatusCode, string(resp.Body), err)
+	}
+
+	// requests which cannot be sent are reported by the status code
+	if resp, err := client.Do(ctx, Request{Method: "GET", URL: server.URL, ServiceUser: true}); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatal("Service user not checked", resp.StatusCode, err)
+	}
+	if resp, err := client.Do(context.Background(), Request{Method: "GET", URL: server.URL}); err == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatal("Token not checked", resp.StatusCode, err)
+	}
+	if resp, err := client.Do(ctx, Request{Method: "GET", URL: "://invalid"}); err == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("Invalid URL accepted", resp.StatusCode, err)
+	}
+}
+
+func TestVerbsUseDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "value" || r.Header.Get("Authorization") != "Bearer test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/existing":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"name":"existing"}`))
+		case "/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"invalid"}`))
+		default:
+			w.Write([]byte(`{"name":"` + r.Method + `"}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := ContextWithHeaders(testContext(), http.Header{"X-Custom": []string{"value"}})
+	client := NewClient(Config{NotApplyServiceUser: true})
+
+	if body, code, err := client.Post(ctx, server.URL, strings.NewReader("{}"), "application/json"); err != nil || code != http.StatusOK || string(body) != `{"name":"POST"}` {
+		t.Fatal("POST failed", code, string(body), err)
+	}
+	if body, code, err := client.Patch(ctx, server.URL, strings.NewReader("{}"), "application/json"); err != nil || code != http.StatusOK || string(body) != `{"name":"PATCH"}` {
+		t.Fatal("PATCH failed", code, string(body), err)
+	}
+	if body, code, err := client.Delete(ctx, server.URL); err != nil || code != http.StatusOK || len(body) != 0 {
+		t.Fatal("DELETE failed", code, string(body), err)
+	}
+
+	// an existing resource is not reported as error by POST
+	if body, code, err := client.Post(ctx, server.URL+"/existing", strings.NewReader("{}"), "application/json"); err != nil || code != http.StatusConflict || string(body) != `{"name":"existing"}` {
+		t.Fatal("Wrong conflict", code, string(body), err)
+	}
+	if body, code, err := client.Patch(ctx, server.URL+"/invalid", strings.NewReader("{}"), "application/json"); err == nil || code != http.StatusUnprocessableEntity || string(body) != `{"message":"invalid"}` {
+		t.Fatal("Wrong error", code, string(body), err)
+	}
+	if body, code, err := client.Delete(ctx, "://invalid"); err == nil || code != http.StatusBadRequest || body == nil {
+		t.Fatal("Invalid URL accepted", code, err)
+	}
+}
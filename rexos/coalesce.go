@@ -0,0 +1,92 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// flight is a GET request which is in progress
+type flight struct {
+	done     chan struct{}
+	response *Response
+	err      error
+	canceled bool // the context of the request got canceled
+}
+
+// flightGroup coalesces concurrent GET requests, so that only one request per credential and
+// URL is sent at a time. All callers get the response of this request.
+type flightGroup struct {
+	mutex   sync.Mutex
+	flights map[string]*flight
+}
+
+// do calls fn unless a call with the same key is in progress, in which case it waits for the
+// response of that call. If the context of the running call gets canceled, waiting callers with
+// a valid context perform the call on their own.
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (*Response, error)) (*Response, error) {
+	g.mutex.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, ok := g.flights[key]; ok {
+		g.mutex.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			code, err := requestError(ctx, ctx.Err())
+			return &Response{StatusCode: code}, err
+		}
+		if f.canceled && ctx.Err() == nil {
+			return fn()
+		}
+		return f.response.copy(), f.err
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mutex.Unlock()
+
+	f.response, f.err = fn()
+	f.canceled = ctx.Err() != nil
+
+	g.mutex.Lock()
+	delete(g.flights, key)
+	g.mutex.Unlock()
+	close(f.done)
+
+	return f.response, f.err
+}
+
+// copy returns a copy of the response, so that the callers of a coalesced request do not share
+// their responses
+func (r *Response) copy() *Response {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	if r.Header != nil {
+		c.Header = r.Header.Clone()
+	}
+	if r.Body != nil {
+		c.Body = append([]byte(nil), r.Body...)
+	}
+	return &c
+}
+
+// coalescable returns true if a GET request with the context may share its response with
+// concurrent requests. Calls which bypass the cache, send additional headers, capture the
+// response header or have their own timeout or retry policy are always sent on their own.
+func coalescable(ctx context.Context) bool {
+	if bypass, _ := ctx.Value(cacheBypassKey{}).(bool); bypass {
+		return false
+	}
+	if ctx.Value(callTimeoutKey{}) != nil || ctx.Value(retryPolicyKey{}) != nil {
+		return false
+	}
+	if _, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		return false
+	}
+	_, ok := ctx.Value(responseHeaderKey{}).(*http.Header)
+	return !ok
+}
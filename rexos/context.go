@@ -3,16 +3,29 @@ package rexos
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/trace"
 )
 
 const (
 	// ContextDataKey is the identifier for getting the context data
 	ContextDataKey = "data"
+
+	// RequestTimeout is the deadline for all REXos calls which are made within the context
+	// returned by GetRexContext
+	RequestTimeout = time.Second * 2
 )
 
+// headersKey is used for storing additional request headers of a single call in the context
+type headersKey struct{}
+
+// responseHeaderKey is used for passing the response header of a single call to the caller
+type responseHeaderKey struct{}
+
 // XForwarded header information
 type XForwarded struct {
 	Host   string
@@ -30,10 +43,14 @@ type ContextData struct {
 	AccessToken string
 	UserID      string
 	XForwarded  XForwarded
+	Trace       trace.SpanContext
+	RequestID   string
 }
 
 // GetRexContext parses the GIN context and extracts the necessary token, while
-// adding the token to a new context for the REXos calls.
+// adding the token to a new context for the REXos calls. The new context is derived from
+// the context of the incoming request, so that REXos calls get canceled as soon as the
+// caller goes away. Additionally a deadline of RequestTimeout is applied.
 func GetRexContext(c *gin.Context) (context.Context, context.CancelFunc) {
 	var contextData ContextData
 	contextData.AccessToken = c.GetHeader(AuthorizationKey)
@@ -52,9 +69,15 @@ func GetRexContext(c *gin.Context) (context.Context, context.CancelFunc) {
 	contextData.XForwarded.Prefix = c.Request.Header.Get("X-Forwarded-Prefix")
 	contextData.XForwarded.Proto = c.Request.Header.Get("X-Forwarded-Proto")
 	contextData.XForwarded.For = c.Request.Header.Get("X-Forwarded-For")
+	contextData.RequestID = c.GetString(event.RequestIDKey)
+	if sc, ok := trace.Extract(c.Request.Header); ok {
+		contextData.Trace = sc
+	} else {
+		contextData.Trace = trace.NewTrace()
+	}
 
-	ctx := context.WithValue(context.Background(), ContextDataKey, contextData)
-	return context.WithTimeout(ctx, time.Second*2)
+	ctx := context.WithValue(c.Request.Context(), ContextDataKey, contextData)
+	return context.WithTimeout(ctx, RequestTimeout)
 }
 
 // GetUserIDFromContext retruns the user id from the context
@@ -83,3 +106,52 @@ func GetXForwarded(ctx context.Context) (XForwarded, error) {
 	}
 	return contextData.(ContextData).XForwarded, nil
 }
+
+// ContextWithHeaders returns a new context which makes all REXos calls using this context send
+// the given headers in addition. Headers which are already set by the client or by a parent
+// context are replaced.
+func ContextWithHeaders(ctx context.Context, header http.Header) context.Context {
+	if parent, ok := ctx.Value(headersKey{}).(http.Header); ok {
+		merged := parent.Clone()
+		for k, v := range header {
+			merged[k] = v
+		}
+		header = merged
+	}
+	return context.WithValue(ctx, headersKey{}, header)
+}
+
+// setContextHeaders sets the additional headers of the context on the request
+func setContextHeaders(ctx context.Context, req *http.Request) {
+	header, ok := ctx.Value(headersKey{}).(http.Header)
+	if !ok {
+		return
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+}
+
+// setRequestID forwards the request ID of the ContextData, so that the logs of all services
+// involved in a request can be correlated
+func setRequestID(ctx context.Context, req *http.Request) {
+	if data, ok := ctx.Value(ContextDataKey).(ContextData); ok && data.RequestID != "" {
+		req.Header.Set(event.RequestIDHeader, data.RequestID)
+	}
+}
+
+// contextWithResponseHeader returns a new context which captures the header of the response of
+// the REXos call using this context
+func contextWithResponseHeader(ctx context.Context) (context.Context, *http.Header) {
+	header := &http.Header{}
+	return context.WithValue(ctx, responseHeaderKey{}, header), header
+}
+
+// captureHeader stores the header of the response, if the context asks for it
+func captureHeader(ctx context.Context, resp *http.Response) {
+	header, ok := ctx.Value(responseHeaderKey{}).(*http.Header)
+	if !ok || resp == nil {
+		return
+	}
+	*header = resp.Header.Clone()
+}
@@ -0,0 +1,25 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDForwarding(t *testing.T) {
+	var requestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), ContextDataKey, ContextData{AccessToken: "Bearer test", RequestID: "abc-123"})
+	service := NewService(Config{NotApplyServiceUser: true})
+	service.DeleteResource(ctx, "Project", server.URL)
+
+	if requestID != "abc-123" {
+		t.Fatal("Request ID not forwarded", requestID)
+	}
+}
@@ -0,0 +1,88 @@
+package rexos
+
+import (
+	"context"
+	"sync"
+
+	"github.com/roboticeyes/gococo/status"
+)
+
+// DefaultFanOutWorkers is the number of concurrent tasks of FanOut if none is given
+const DefaultFanOutWorkers = 8
+
+// FanOutMode defines how FanOut handles failing tasks
+type FanOutMode int
+
+const (
+	// FailFast cancels the context of the running tasks after the first failure and does not
+	// start any further tasks
+	FailFast FanOutMode = iota
+
+	// CollectAll runs all tasks regardless of failures
+	CollectAll
+)
+
+// FanOut runs the task for every index from 0 to n-1 with at most the given number of workers
+// (DefaultFanOutWorkers if <= 0). The tasks store their results by index, so that the order of
+// the input is kept. The statuses of all tasks are returned by index together with the first
+// failure, which is nil if all tasks succeeded. Tasks which have not been started because the
+// context got canceled report the status of the context.
+//
+// Concurrent GET requests of the tasks for the same resource and credentials are coalesced by
+// the client, so fetching the same resource for several indices costs a single request.
+func FanOut(ctx context.Context, n, workers int, mode FanOutMode, task func(ctx context.Context, i int) *status.Status) ([]*status.Status, *status.Status) {
+
+	if workers <= 0 {
+		workers = DefaultFanOutWorkers
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	statuses := make([]*status.Status, n)
+	started := make([]bool, n)
+	var mutex sync.Mutex
+	var failure *status.Status
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				ret := task(ctx, i)
+				statuses[i] = ret
+				if ret != nil && mode == FailFast {
+					mutex.Lock()
+					if failure == nil {
+						failure = ret
+					}
+					mutex.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case indices <- i:
+			started[i] = true
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	for i := range statuses {
+		if !started[i] {
+			statuses[i] = newRequestStatus(nil, 0, ctx.Err(), "Task has not been started")
+		}
+		if failure == nil && statuses[i] != nil {
+			failure = statuses[i]
+		}
+	}
+	return statuses, failure
+}
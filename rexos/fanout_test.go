@@ -0,0 +1,132 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/roboticeyes/gococo/status"
+)
+
+func TestFanOutKeepsOrderAndBoundsWorkers(t *testing.T) {
+	var running, maxRunning int32
+	results := make([]int, 20)
+
+	statuses, ret := FanOut(context.Background(), len(results), 3, CollectAll, func(ctx context.Context, i int) *status.Status {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * time.Duration(20-i))
+		results[i] = i * i
+		return nil
+	})
+	if ret != nil || len(statuses) != len(results) {
+		t.Fatal("Fan-out failed", ret)
+	}
+	for i, r := range results {
+		if r != i*i {
+			t.Fatal("Wrong result at", i, r)
+		}
+	}
+	if maxRunning > 3 {
+		t.Fatal("Too many concurrent tasks", maxRunning)
+	}
+}
+
+func TestFanOutModes(t *testing.T) {
+	failing := func(ctx context.Context, i int) *status.Status {
+		if i == 1 || i == 3 {
+			return status.NewStatus(nil, http.StatusNotFound, "not found")
+		}
+		return nil
+	}
+
+	statuses, ret := FanOut(context.Background(), 5, 1, CollectAll, failing)
+	if ret == nil || statuses[1] == nil || statuses[3] == nil || statuses[4] != nil {
+		t.Fatal("Not all tasks have been run", statuses)
+	}
+
+	statuses, ret = FanOut(context.Background(), 5, 1, FailFast, failing)
+	if ret == nil || ret.Code != http.StatusNotFound {
+		t.Fatal("Wrong failure", ret)
+	}
+	if statuses[4] == nil || statuses[4].Code == http.StatusNotFound {
+		t.Fatal("Task has been started after the failure", statuses[4])
+	}
+}
+
+func TestCoalescedGet(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte(`{"name":"project"}`))
+	}))
+	defer server.Close()
+
+	service := NewService(Config{NotApplyServiceUser: true})
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := service.GetHalResource(testContext(), "Project", server.URL)
+			bodies[i] = string(body)
+		}(i)
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+
+	if requests != 1 {
+		t.Fatal("Requests have not been coalesced", requests)
+	}
+	for _, body := range bodies {
+		if body != `{"name":"project"}` {
+			t.Fatal("Wrong body", body)
+		}
+	}
+}
+
+func TestNoCoalescingWithCallSettings(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.Write([]byte(`{"name":"project"}`))
+	}))
+	defer server.Close()
+
+	// calls with their own timeout or retry policy must not share the outcome of another call
+	service := NewService(Config{NotApplyServiceUser: true})
+	opts := []CallOption{WithCallTimeout(5 * time.Second), WithCallRetryPolicy(NoRetry), WithCallTimeout(5 * time.Second), WithCallRetryPolicy(NoRetry)}
+	var wg sync.WaitGroup
+	for _, opt := range opts {
+		wg.Add(1)
+		go func(opt CallOption) {
+			defer wg.Done()
+			if _, ret := service.GetResource(testContext(), "Project", server.URL, opt); ret != nil {
+				t.Error("Request failed", ret)
+			}
+		}(opt)
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&requests); n != int32(len(opts)) {
+		t.Fatal("Requests have been coalesced", n)
+	}
+}
@@ -0,0 +1,212 @@
+package rexos
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/status"
+	"github.com/tidwall/gjson"
+)
+
+// IntegrityError is returned if the hash of a transferred file does not match the contentHash
+// of REXos
+type IntegrityError struct {
+	FileName string
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("Content hash of file %s does not match: expected %s, got %s", e.FileName, e.Expected, e.Actual)
+}
+
+// ContentHash computes the hash of the content in the same way as the contentHash of REXos. The
+// contentHash of the file links of REXos is a hex encoded 128 bit digest, i.e. MD5 (e.g.
+// contentHash=2dd1aee5e71621ea56042c92886be464 of the file link of project file 1747 on
+// api-dev-01.rexos.cloud). Use ContentHashLike to compare with a hash of another algorithm.
+func ContentHash(content io.Reader) (string, error) {
+	return computeHash(md5.New(), content)
+}
+
+// ContentHashLike computes the hash of the content with the algorithm of the given contentHash,
+// which is determined by the length of its digest (MD5, SHA-1 or SHA-256). If the given hash is
+// empty, MD5 is used like by ContentHash.
+func ContentHashLike(content io.Reader, contentHash string) (string, error) {
+	if contentHash == "" {
+		return ContentHash(content)
+	}
+	h, err := newContentHash(contentHash)
+	if err != nil {
+		return "", err
+	}
+	return computeHash(h, content)
+}
+
+// newContentHash returns the hash algorithm of the hex encoded contentHash based on the length
+// of its digest
+func newContentHash(contentHash string) (hash.Hash, error) {
+	if _, err := hex.DecodeString(contentHash); err != nil {
+		return nil, fmt.Errorf("Invalid content hash %s", contentHash)
+	}
+	switch len(contentHash) {
+	case 2 * md5.Size:
+		return md5.New(), nil
+	case 2 * sha1.Size:
+		return sha1.New(), nil
+	case 2 * sha256.Size:
+		return sha256.New(), nil
+	}
+	return nil, fmt.Errorf("Unsupported content hash %s", contentHash)
+}
+
+func computeHash(h hash.Hash, content io.Reader) (string, error) {
+	if _, err := io.Copy(h, content); err != nil {
+		return "", fmt.Errorf("Cannot compute content hash: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyingReader computes the hash of the content while it is read. Instead of io.EOF an
+// IntegrityError is returned if the hash does not match.
+type verifyingReader struct {
+	io.ReadCloser
+	fileName string
+	expected string
+	hash     hash.Hash
+}
+
+// newVerifyingReader wraps the reader, so that the content is verified against the expected
+// hash. If no hash is expected, the reader is returned as it is. An error is returned if the
+// algorithm of the expected hash is not supported.
+func newVerifyingReader(r io.ReadCloser, fileName, expected string) (io.ReadCloser, error) {
+	if expected == "" {
+		return r, nil
+	}
+	expected = strings.ToLower(expected)
+	h, err := newContentHash(expected)
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{
+		ReadCloser: r,
+		fileName:   fileName,
+		expected:   expected,
+		hash:       h,
+	}, nil
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.ReadCloser.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF {
+		if actual := hex.EncodeToString(v.hash.Sum(nil)); actual != v.expected {
+			return n, &IntegrityError{FileName: v.fileName, Expected: v.expected, Actual: actual}
+		}
+	}
+	return n, err
+}
+
+// DownloadFileStreamVerified opens the file like DownloadFileStream, but verifies the content
+// against the contentHash of the download link while it is read. On a mismatch reading the
+// download fails with an IntegrityError at the end of the content. Links without contentHash
+// are not verified.
+func (s *Service) DownloadFileStreamVerified(ctx context.Context, downloadURL string, authenticate bool) (*FileDownload, *status.Status) {
+	download, ret := s.DownloadFileStream(ctx, downloadURL, authenticate)
+	if ret != nil {
+		return nil, ret
+	}
+	r, err := newVerifyingReader(download.ReadCloser, download.FileName, GetHashFromDownloadLink(downloadURL))
+	if err != nil {
+		download.Close()
+		log.WithContext(ctx).WithFields(event.Fields{
+			"downloadUrl": downloadURL,
+		}).Error("Can not verify file content: " + err.Error())
+		return nil, status.NewStatus(nil, http.StatusBadGateway, "Can not access file "+downloadURL+": "+err.Error())
+	}
+	download.ReadCloser = r
+	return download, nil
+}
+
+// DownloadFileContentVerified downloads the file like DownloadFileContent, but verifies the
+// content against the contentHash of the download link. Links without contentHash are not
+// verified.
+func (s *Service) DownloadFileContentVerified(ctx context.Context, downloadURL string, authenticate bool) ([]byte, *status.Status) {
+	blob, ret := s.DownloadFileContent(ctx, downloadURL, authenticate)
+	if ret != nil {
+		return blob, ret
+	}
+
+	expected := GetHashFromDownloadLink(downloadURL)
+	if expected == "" {
+		return blob, nil
+	}
+	actual, err := ContentHashLike(bytes.NewReader(blob), expected)
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"downloadUrl": downloadURL,
+		}).Error("Can not verify file content: " + err.Error())
+		return []byte{}, status.NewStatus(nil, http.StatusBadGateway, "Can not access file "+downloadURL+": "+err.Error())
+	}
+	if actual != strings.ToLower(expected) {
+		err := &IntegrityError{FileName: downloadURL, Expected: expected, Actual: actual}
+		log.WithContext(ctx).WithFields(event.Fields{
+			"downloadUrl": downloadURL,
+		}).Error(err.Error())
+		return []byte{}, newRequestStatus(nil, 0, err, "Can not access file "+downloadURL)
+	}
+	return blob, nil
+}
+
+// GetProjectFileHash returns the contentHash of the file of the given project file. An empty
+// string is returned if no file has been uploaded yet.
+func (s *Service) GetProjectFileHash(ctx context.Context, projectFileURL string) (string, *status.Status) {
+	projectFile, ret := s.GetHalResource(ctx, "ProjectFile", projectFileURL)
+	if ret != nil {
+		return "", ret
+	}
+	return GetHashFromDownloadLink(gjson.GetBytes(projectFile, "_links.file.href").String()), nil
+}
+
+// UploadFileIfChanged uploads the byte array as file, unless the project file already contains
+// the same content. The upload URL is expected to be the file link of the project file. If the
+// contentHash of the project file cannot be determined, the file is uploaded. It returns true if
+// the file has been uploaded.
+func (s *Service) UploadFileIfChanged(ctx context.Context, fileName string, uploadURL string, data []byte) (bool, *status.Status) {
+
+	current, ret := s.GetProjectFileHash(ctx, projectFileURLFromUpload(uploadURL))
+	if ret != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"uploadUrl": uploadURL,
+			"fileName":  fileName,
+			"status":    ret,
+		}).Debug("Cannot get content hash of project file, uploading file")
+		current = ""
+	}
+	if contentHash, err := ContentHashLike(bytes.NewReader(data), current); err == nil && current != "" && contentHash == strings.ToLower(current) {
+		log.WithContext(ctx).WithFields(event.Fields{
+			"uploadUrl": uploadURL,
+			"fileName":  fileName,
+		}).Debug("File content is unchanged, skipping upload")
+		return false, nil
+	}
+
+	if ret := s.UploadFile(ctx, fileName, uploadURL, data); ret != nil {
+		return false, ret
+	}
+	return true, nil
+}
+
+// projectFileURLFromUpload returns the self link of the project file for its file link
+func projectFileURLFromUpload(uploadURL string) string {
+	return strings.TrimSuffix(StripTemplateParameter(uploadURL), "/file")
+}
@@ -0,0 +1,117 @@
+package rexos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestVerifyingReader(t *testing.T) {
+	expected, _ := ContentHash(strings.NewReader("content"))
+
+	r, _ := newVerifyingReader(ioutil.NopCloser(strings.NewReader("content")), "file.rex", expected)
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatal("Valid content rejected", err)
+	}
+
+	r, _ = newVerifyingReader(ioutil.NopCloser(strings.NewReader("corrupted")), "file.rex", expected)
+	_, err := ioutil.ReadAll(r)
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Fatal("Corrupted content not detected", err)
+	}
+
+	// the algorithm is chosen by the length of the digest
+	sha256Hash := "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+	r, _ = newVerifyingReader(ioutil.NopCloser(strings.NewReader("content")), "file.rex", strings.ToUpper(sha256Hash))
+	if _, err := ioutil.ReadAll(r); err != nil {
+		t.Fatal("Valid content rejected", err)
+	}
+	if _, err := newVerifyingReader(ioutil.NopCloser(strings.NewReader("content")), "file.rex", "abc123"); err == nil {
+		t.Fatal("Unsupported hash accepted")
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	tests := map[string]string{
+		"":                                 "9a0364b9e99bb480dd25e1f0284c8555",
+		"2dd1aee5e71621ea56042c92886be464": "9a0364b9e99bb480dd25e1f0284c8555",
+		"0000000000000000000000000000000000000000":                         "040f06fd774092478d450774f5ba30c5da78acc8",
+		"ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73": "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73",
+	}
+	for reference, expected := range tests {
+		if h, err := ContentHashLike(strings.NewReader("content"), reference); err != nil || h != expected {
+			t.Error("Wrong hash", reference, h, err)
+		}
+	}
+	if _, err := ContentHashLike(strings.NewReader("content"), "xyz"); err == nil {
+		t.Fatal("Invalid hash accepted")
+	}
+}
+
+func TestGetHashFromDownloadLink(t *testing.T) {
+	tests := map[string]string{
+		"https://rexos/api/v2/projectFiles/1747/file?contentHash=2dd1aee5e71621ea56042c92886be464":              "2dd1aee5e71621ea56042c92886be464",
+		"https://rexos/api/v2/projectFiles/1747/file?projection=x&contentHash=2dd1aee5e71621ea56042c92886be464": "2dd1aee5e71621ea56042c92886be464",
+		"https://rexos/api/v2/projectFiles/1747/file?contentHash=2dd1aee5e71621ea56042c92886be464&version=2":    "2dd1aee5e71621ea56042c92886be464",
+		"https://rexos/api/v2/projectFiles/1747/file?token=a=b":                                                 "",
+		"https://rexos/api/v2/projectFiles/1747/file":                                                           "",
+	}
+	for link, expected := range tests {
+		if h := GetHashFromDownloadLink(link); h != expected {
+			t.Error("Wrong hash", link, h)
+		}
+	}
+}
+
+func TestUploadFileIfChanged(t *testing.T) {
+	content := []byte("content")
+	contentHash, _ := ContentHash(bytes.NewReader(content))
+
+	var projectFile atomic.Value
+	var uploads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/projectFiles/1/file":
+			atomic.AddInt32(&uploads, 1)
+		case r.Method == "GET" && r.URL.Path == "/projectFiles/1":
+			hash := projectFile.Load().(string)
+			if hash == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"_links":{"file":{"href":"http://%s/projectFiles/1/file?contentHash=%s"}}}`, r.Host, hash)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	for _, tc := range []struct {
+		contentHash string
+		uploaded    bool
+	}{
+		{contentHash, false},
+		{strings.ToUpper(contentHash), false},
+		{strings.Repeat("0", len(contentHash)), true},
+		// the lookup of the hash fails, e.g. for a project file without content
+		{"", true},
+	} {
+		projectFile.Store(tc.contentHash)
+		before := atomic.LoadInt32(&uploads)
+		uploaded, ret := service.UploadFileIfChanged(testContext(), "scan.rex", server.URL+"/projectFiles/1/file", content)
+		if ret != nil || uploaded != tc.uploaded {
+			t.Fatal("Wrong upload", tc.contentHash, uploaded, ret)
+		}
+		if sent := atomic.LoadInt32(&uploads) - before; (sent == 1) != tc.uploaded {
+			t.Fatal("Wrong number of uploads", tc.contentHash, sent)
+		}
+	}
+}
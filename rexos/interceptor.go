@@ -26,10 +26,16 @@ type Interceptor struct {
 
 // NewInterceptor creates a watcher for the session token information
 func NewInterceptor(sessionFile string) *Interceptor {
+	return NewInterceptorWithHTTPClient(sessionFile, http.DefaultClient)
+}
+
+// NewInterceptorWithHTTPClient creates a watcher for the session token information, which uses
+// the given HTTP client (e.g. Client.HTTPClient) instead of http.DefaultClient
+func NewInterceptorWithHTTPClient(sessionFile string, httpClient *http.Client) *Interceptor {
 
 	i := &Interceptor{
 		sessionFile: sessionFile,
-		httpClient:  http.DefaultClient,
+		httpClient:  httpClient,
 	}
 	i.loadToken()
 
@@ -33,13 +33,15 @@ type ProjectInvitation struct {
 
 // CreateProjectInvitation shares a project with a new user
 func (s *Service) CreateProjectInvitation(ctx context.Context, projectUrn string, projectInvitation ProjectInvitation, projectResourceURL, userResourceURL, invitationURL, rexCodesResourceURL string) (ProjectInvitation, *status.Status) {
-	// find project
-	query := QueryFindByUrn(projectResourceURL, projectUrn)
-	projectResult, ret := s.GetHalResource(ctx, "Project", query)
+	// find project, its links are used for sharing it and must not point to the external gateway
+	var projectResult []byte
+	projectTraversal, ret := s.TraverseUrn(projectResourceURL, projectUrn).WithOptions(WithXForwarded(false)).Fetch(ctx)
+	if ret == nil {
+		projectResult, ret = projectTraversal.Get(ctx)
+	}
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"projectUrn": projectUrn,
-			"query":      query,
 			"status":     ret,
 		}).Error("Failed to get project")
 
@@ -52,7 +54,7 @@ func (s *Service) CreateProjectInvitation(ctx context.Context, projectUrn string
 	// find key of portal reference
 	key := gjson.Get(string(projectResult), "_embedded.rexReferences.#(type==\"portal\").key")
 
-	query = invitationURL
+	query := invitationURL
 	var invitation UserAndProjectData
 	invitation.Email = projectInvitation.User.Email
 	invitation.FirstName = projectInvitation.User.FirstName
@@ -62,7 +64,7 @@ func (s *Service) CreateProjectInvitation(ctx context.Context, projectUrn string
 
 	invResult, ret := s.CreateHalResourceWithXF(ctx, "Auth", query, invitation)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status": ret,
 			"query":  query,
 		}).Error("Failed to create invitation")
@@ -79,18 +81,15 @@ func (s *Service) CreateProjectInvitation(ctx context.Context, projectUrn string
 		action = readAction
 	}
 
-	projectNumber, ret := GetNumberFromUrn(projectUrn)
-	if ret != nil {
-		return projectInvitation, ret
-	}
-
 	share := UserShareReduced{UserID: userID, Action: action}
 
 	// update user sharing
-	query = projectResourceURL + "/" + projectNumber + "/userShares"
-	_, ret = s.CreateHalResource(ctx, "Projects", query, share)
+	query, ret = projectTraversal.URL(ctx, "userShares")
+	if ret == nil {
+		_, ret = s.CreateHalResource(ctx, "Projects", query, share)
+	}
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status":     ret,
 			"projectUrn": projectUrn,
 			"query":      query,
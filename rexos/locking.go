@@ -0,0 +1,89 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/status"
+)
+
+// DefaultUpdateTrials is the number of trials of UpdateHalResource if none is given
+const DefaultUpdateTrials = 3
+
+// MutateFunc returns the partial update for the current state of a resource. If nil is
+// returned, the resource is not modified.
+type MutateFunc func(current []byte) (interface{}, *status.Status)
+
+// GetVersionedHalResource returns the requested resource together with its version (ETag),
+// which can be used for PatchVersionedHalResource. The response cache is bypassed, so that the
+// version is always the current one.
+func (s *Service) GetVersionedHalResource(ctx context.Context, resourceName, url string) ([]byte, string, *status.Status) {
+	ctx, header := contextWithResponseHeader(ctx)
+	body, ret := s.GetResource(ctx, resourceName, url, WithoutCache())
+	if ret != nil {
+		return body, "", ret
+	}
+	return body, header.Get("ETag"), nil
+}
+
+// PatchVersionedHalResource patches the resource only if it still has the given version. If
+// the resource got modified in the meantime, a status is returned which reports
+// IsOptimisticLockingFailure. An empty version patches the resource unconditionally.
+func (s *Service) PatchVersionedHalResource(ctx context.Context, resourceName, url, version string, r interface{}) ([]byte, *status.Status) {
+	var opts []CallOption
+	if version != "" {
+		opts = append(opts, WithHeader("If-Match", version))
+	}
+
+	body, ret := s.PatchResource(ctx, resourceName, url, r, opts...)
+	if ret != nil && (ret.Code == http.StatusPreconditionFailed || ret.Code == http.StatusConflict) {
+		// Spring Data REST reports a version mismatch of If-Match as 412, a concurrent
+		// modification detected on commit (OptimisticLockingFailureException) as 409
+		ret.InternalStatus.Type = status.TypeOptimisticLockingFailure
+	}
+	return body, ret
+}
+
+// UpdateHalResource fetches the resource, applies the mutation and patches the resource with
+// the version it was read with. If the resource got modified in the meantime, it is fetched
+// again and the mutation is re-applied, up to the given number of trials (DefaultUpdateTrials
+// if <= 0). The body of the patched resource is returned, or the current one if the mutation
+// did not return any update.
+func (s *Service) UpdateHalResource(ctx context.Context, resourceName, url string, trials int, mutate MutateFunc) ([]byte, *status.Status) {
+
+	if trials <= 0 {
+		trials = DefaultUpdateTrials
+	}
+
+	var ret *status.Status
+	for trial := 1; trial <= trials; trial++ {
+		var current []byte
+		var version string
+		current, version, ret = s.GetVersionedHalResource(ctx, resourceName, url)
+		if ret != nil {
+			return []byte{}, ret
+		}
+
+		update, mret := mutate(current)
+		if mret != nil {
+			return []byte{}, mret
+		}
+		if update == nil {
+			return current, nil
+		}
+
+		var body []byte
+		body, ret = s.PatchVersionedHalResource(ctx, resourceName, url, version, update)
+		if !ret.IsOptimisticLockingFailure() {
+			return body, ret
+		}
+
+		log.WithContext(ctx).WithFields(event.Fields{
+			"resourceName": resourceName,
+			"url":          url,
+			"trial":        trial,
+		}).Debug("Resource got modified concurrently, applying update again")
+	}
+	return []byte{}, ret
+}
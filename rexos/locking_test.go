@@ -0,0 +1,68 @@
+package rexos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/roboticeyes/gococo/status"
+)
+
+func TestUpdateHalResource(t *testing.T) {
+	for _, conflict := range []int{http.StatusPreconditionFailed, http.StatusConflict} {
+		testUpdateHalResource(t, conflict)
+	}
+}
+
+func testUpdateHalResource(t *testing.T, conflict int) {
+	version := 1
+	patches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"%d"`, version)
+		switch r.Method {
+		case "GET":
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(`{"name":"project"}`))
+		case "PATCH":
+			patches++
+			if patches == 1 {
+				// concurrent modification by another client
+				version++
+			}
+			if r.Header.Get("If-Match") != fmt.Sprintf(`"%d"`, version) {
+				w.WriteHeader(conflict)
+				return
+			}
+			version++
+			w.Write([]byte(`{"name":"updated"}`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	mutations := 0
+	body, ret := service.UpdateHalResource(testContext(), "Project", server.URL, 0, func(current []byte) (interface{}, *status.Status) {
+		mutations++
+		return Project{Name: "updated"}, nil
+	})
+	if ret != nil || string(body) != `{"name":"updated"}` {
+		t.Fatal("Update failed", conflict, string(body), ret)
+	}
+	if mutations != 2 {
+		t.Fatal("Mutation not re-applied", conflict, mutations)
+	}
+}
+
+func TestPatchVersionedHalResourceConflict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	_, ret := service.PatchVersionedHalResource(testContext(), "Project", server.URL, `"1"`, Project{Name: "updated"})
+	if !ret.IsOptimisticLockingFailure() || ret.Code != http.StatusConflict {
+		t.Fatal("Conflict not reported as optimistic locking failure", ret)
+	}
+}
@@ -0,0 +1,70 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/roboticeyes/gococo/metrics"
+)
+
+// resourceNameKey is used for storing the resource name of a single call in the context
+type resourceNameKey struct{}
+
+var (
+	requestsTotal = metrics.NewCounter(metrics.Namespace+"_rexos_client_requests_total",
+		"Number of REXos calls by verb, resource name and status code.", "verb", "resource", "code")
+	requestDuration = metrics.NewHistogram(metrics.Namespace+"_rexos_client_request_duration_seconds",
+		"Duration of REXos calls including retries.", nil, "verb", "resource")
+	retriesTotal = metrics.NewCounter(metrics.Namespace+"_rexos_client_retries_total",
+		"Number of retried REXos requests by verb and resource name.", "verb", "resource")
+	tokenRefreshesTotal = metrics.NewCounter(metrics.Namespace+"_rexos_token_refreshes_total",
+		"Number of service user token refreshes by result.", "result")
+)
+
+// RegisterMetrics registers the metrics of all REXos calls at the given registry, e.g.
+// metrics.DefaultRegistry. The metrics are recorded in any case, but only exposed once
+// registered.
+func RegisterMetrics(registry *metrics.Registry) error {
+	return registry.Register(requestsTotal, requestDuration, retriesTotal, tokenRefreshesTotal)
+}
+
+// contextWithResourceName returns a new context which labels the metrics of all REXos calls
+// using this context with the given resource name
+func contextWithResourceName(ctx context.Context, resourceName string) context.Context {
+	return context.WithValue(ctx, resourceNameKey{}, resourceName)
+}
+
+// resourceNameFromContext returns the resource name of the call, or "unknown" if the call has
+// not been made by the service
+func resourceNameFromContext(ctx context.Context) string {
+	if resourceName, _ := ctx.Value(resourceNameKey{}).(string); resourceName != "" {
+		return resourceName
+	}
+	return "unknown"
+}
+
+// observeRequest records the metrics of a call which took the given trials
+func observeRequest(ctx context.Context, method string, resp *http.Response, trials int, err error, d time.Duration) {
+	resourceName := resourceNameFromContext(ctx)
+	code := "error"
+	if err == nil && resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	requestsTotal.Inc(method, resourceName, code)
+	requestDuration.Observe(d.Seconds(), method, resourceName)
+	if trials > 1 {
+		retriesTotal.Add(float64(trials-1), method, resourceName)
+	}
+}
+
+// observeTokenRefresh records the result of a service user token refresh
+func observeTokenRefresh(success bool) {
+	if success {
+		tokenRefreshesTotal.Inc("success")
+	} else {
+		tokenRefreshesTotal.Inc("failure")
+	}
+}
@@ -0,0 +1,59 @@
+package rexos
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/roboticeyes/gococo/metrics"
+)
+
+func TestMetricsOfServiceCalls(t *testing.T) {
+	var trials int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&trials, 1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	service, _ := NewServiceWithOptions(Config{NotApplyServiceUser: true}, WithRetryPolicy(policy))
+
+	requests := requestsTotal.Value("GET", "MetricsTest", "200")
+	retries := retriesTotal.Value("GET", "MetricsTest")
+
+	if _, ret := service.GetResource(testContext(), "MetricsTest", server.URL); ret != nil {
+		t.Fatal("Request failed", ret)
+	}
+	if requestsTotal.Value("GET", "MetricsTest", "200") != requests+1 {
+		t.Fatal("Request has not been counted")
+	}
+	if retriesTotal.Value("GET", "MetricsTest") != retries+1 {
+		t.Fatal("Retry has not been counted")
+	}
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	if err := RegisterMetrics(registry); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterMetrics(registry); !errors.Is(err, metrics.ErrAlreadyRegistered) {
+		t.Fatal("Metrics registered twice", err)
+	}
+
+	var b bytes.Buffer
+	registry.Write(&b)
+	if !strings.Contains(b.String(), "# TYPE gococo_rexos_client_requests_total counter\n") {
+		t.Fatal("Metric not exposed", b.String())
+	}
+}
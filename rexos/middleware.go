@@ -0,0 +1,48 @@
+package rexos
+
+import (
+	"net/http"
+)
+
+// Middleware wraps the round tripper of the client, e.g. for adding headers, recording timings
+// or injecting faults. It is called for every single trial of a request, retries included.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is an adapter to use ordinary functions as http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements the http.RoundTripper interface
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// BeforeRequest returns a middleware which calls the hook before the request is sent. The hook
+// gets a clone of the request, which may be modified (e.g. by adding headers). If the hook
+// returns an error, the request is not sent.
+func BeforeRequest(hook func(req *http.Request) error) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			// a round tripper must not modify the original request
+			clone := req.Clone(req.Context())
+			if err := hook(clone); err != nil {
+				if req.Body != nil {
+					req.Body.Close()
+				}
+				return nil, err
+			}
+			return next.RoundTrip(clone)
+		})
+	}
+}
+
+// AfterResponse returns a middleware which calls the hook after the response has been received
+// or the request failed. The body of the response must not be consumed by the hook.
+func AfterResponse(hook func(req *http.Request, resp *http.Response, err error)) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			hook(req, resp, err)
+			return resp, err
+		})
+	}
+}
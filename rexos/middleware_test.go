@@ -0,0 +1,43 @@
+package rexos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "middleware" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	var order []string
+	var codes []int
+	client, _ := NewClientWithOptions(Config{NotApplyServiceUser: true},
+		WithMiddleware(BeforeRequest(func(req *http.Request) error {
+			order = append(order, "first")
+			req.Header.Set("X-Test", "middleware")
+			return nil
+		})),
+		WithMiddleware(BeforeRequest(func(req *http.Request) error {
+			order = append(order, "second")
+			return nil
+		}), AfterResponse(func(req *http.Request, resp *http.Response, err error) {
+			codes = append(codes, resp.StatusCode)
+		})),
+	)
+
+	client.Get(testContext(), server.URL, true)
+	client.Post(testContext(), server.URL, strings.NewReader("{}"), "application/json")
+
+	if strings.Join(order, ",") != "first,second,first,second" {
+		t.Fatal("Wrong order of middlewares", order)
+	}
+	if len(codes) != 2 || codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Fatal("Wrong responses", codes)
+	}
+}
@@ -0,0 +1,279 @@
+package rexos
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures the HTTP connection of a REXos client. The options are applied by
+// NewClientWithOptions and are used for the service user token refresh as well as for all
+// resource calls.
+type ClientOption func(*clientOptions) error
+
+// clientOptions collects all settings before the HTTP client gets created
+type clientOptions struct {
+	transport             bool // set if any option requires a dedicated HTTP client
+	retry                 RetryPolicy
+	breakers              *breakers
+	cache                 *responseCache
+	limiter               *rateLimiter
+	middlewares           []Middleware
+	timeout               time.Duration
+	maxIdleConns          int
+	maxIdleConnsPerHost   int
+	maxConnsPerHost       int
+	idleConnTimeout       time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	roundTripper          http.RoundTripper
+	proxy                 func(*http.Request) (*url.URL, error)
+	rootCAs               *x509.CertPool
+	certificates          []tls.Certificate
+}
+
+// WithTimeout sets the overall timeout of a single request including reading the response body.
+// Please note that this also limits file downloads, use the context deadline for single calls instead.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithConnectionPool sets the maximum number of idle connections in total and per host
+func WithConnectionPool(maxIdleConns, maxIdleConnsPerHost int) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		o.maxIdleConns = maxIdleConns
+		o.maxIdleConnsPerHost = maxIdleConnsPerHost
+		return nil
+	}
+}
+
+// WithMaxConnsPerHost limits the number of connections per host, including the active ones
+func WithMaxConnsPerHost(maxConnsPerHost int) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		o.maxConnsPerHost = maxConnsPerHost
+		return nil
+	}
+}
+
+// WithKeepAlive sets how long an idle connection stays in the pool before it gets closed
+func WithKeepAlive(idleConnTimeout time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		o.idleConnTimeout = idleConnTimeout
+		return nil
+	}
+}
+
+// WithTransportTimeouts sets the timeouts for the TLS handshake and for waiting on the response
+// header after the request has been written
+func WithTransportTimeouts(tlsHandshakeTimeout, responseHeaderTimeout time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		o.tlsHandshakeTimeout = tlsHandshakeTimeout
+		o.responseHeaderTimeout = responseHeaderTimeout
+		return nil
+	}
+}
+
+// WithRoundTripper replaces the transport of the client. All other transport options (connection
+// pool, proxy, TLS) are ignored in this case and must be configured on the given round tripper.
+func WithRoundTripper(roundTripper http.RoundTripper) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		o.roundTripper = roundTripper
+		return nil
+	}
+}
+
+// WithProxy sets the proxy which is used for all requests, e.g. "http://proxy.local:3128". An
+// empty string disables the proxy, which is otherwise taken from the environment.
+func WithProxy(proxyURL string) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		if proxyURL == "" {
+			o.proxy = nil
+			return nil
+		}
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("Invalid proxy URL: %w", err)
+		}
+		o.proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// WithCABundle adds the PEM encoded certificates to the trusted certificate authorities. The
+// certificates of the system are still trusted.
+func WithCABundle(pemCerts []byte) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		if o.rootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			o.rootCAs = pool
+		}
+		if !o.rootCAs.AppendCertsFromPEM(pemCerts) {
+			return fmt.Errorf("No valid certificate found in CA bundle")
+		}
+		return nil
+	}
+}
+
+// WithCAFile reads the PEM encoded CA bundle from the given file, see WithCABundle
+func WithCAFile(fileName string) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		pemCerts, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("Cannot read CA file: %w", err)
+		}
+		return WithCABundle(pemCerts)(o)
+	}
+}
+
+// WithClientCertificate loads the PEM encoded key pair which is used for TLS client authentication
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	return func(o *clientOptions) error {
+		o.transport = true
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("Cannot load client certificate: %w", err)
+		}
+		o.certificates = append(o.certificates, cert)
+		return nil
+	}
+}
+
+// WithRetryPolicy sets the retry policy for all calls of the client. The policy can be
+// overridden for single calls by ContextWithRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) error {
+		o.retry = policy
+		return nil
+	}
+}
+
+// WithCircuitBreaker enables circuit breakers for all calls of the client. Calls to a target
+// whose circuit is open fail fast with ErrCircuitOpen instead of being sent.
+func WithCircuitBreaker(settings BreakerSettings) ClientOption {
+	return func(o *clientOptions) error {
+		o.breakers = newBreakers(settings)
+		return nil
+	}
+}
+
+// WithRateLimit limits the rate of all calls of the client, separately for the service user
+// and for every end-user token. Calls which would have to wait longer than allowed fail with
+// ErrRateLimited instead of being sent.
+func WithRateLimit(settings RateLimitSettings) ClientOption {
+	return func(o *clientOptions) error {
+		o.limiter = newRateLimiter(settings)
+		return nil
+	}
+}
+
+// WithResponseCache enables the response cache for all GET calls of the client. Cached responses
+// are revalidated by conditional GETs (If-None-Match, If-Modified-Since). The cache can be
+// bypassed for single calls by ContextWithoutCache.
+func WithResponseCache(settings CacheSettings) ClientOption {
+	return func(o *clientOptions) error {
+		o.cache = newResponseCache(settings)
+		return nil
+	}
+}
+
+// WithMiddleware registers middlewares which wrap every outbound request of the client,
+// including file downloads and the refresh of the service user token. The middlewares are
+// applied in the given order, the first one being the outermost. Options can be given several
+// times, the middlewares are appended.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(o *clientOptions) error {
+		o.middlewares = append(o.middlewares, middlewares...)
+		return nil
+	}
+}
+
+// applyOptions applies all options on top of the default settings
+func applyOptions(opts ...ClientOption) (clientOptions, error) {
+	o := clientOptions{
+		retry: DefaultRetryPolicy(),
+		proxy: http.ProxyFromEnvironment,
+	}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+// httpClient creates the HTTP client based on the options. If no option requires a dedicated
+// client, http.DefaultClient is returned.
+func (o *clientOptions) httpClient() *http.Client {
+
+	if !o.transport && len(o.middlewares) == 0 {
+		return http.DefaultClient
+	}
+
+	var roundTripper http.RoundTripper
+	switch {
+	case o.roundTripper != nil:
+		roundTripper = o.roundTripper
+	case o.transport:
+		roundTripper = o.newTransport()
+	default:
+		roundTripper = http.DefaultTransport
+	}
+
+	// the first middleware is the outermost one
+	for i := len(o.middlewares) - 1; i >= 0; i-- {
+		roundTripper = o.middlewares[i](roundTripper)
+	}
+
+	return &http.Client{Transport: roundTripper, Timeout: o.timeout}
+}
+
+// newTransport creates the transport based on the settings of http.DefaultTransport
+func (o *clientOptions) newTransport() *http.Transport {
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = o.proxy
+	if o.maxIdleConns > 0 {
+		transport.MaxIdleConns = o.maxIdleConns
+	}
+	if o.maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = o.maxIdleConnsPerHost
+	}
+	if o.maxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = o.maxConnsPerHost
+	}
+	if o.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = o.idleConnTimeout
+	}
+	if o.tlsHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = o.tlsHandshakeTimeout
+	}
+	if o.responseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = o.responseHeaderTimeout
+	}
+	if o.rootCAs != nil || len(o.certificates) > 0 {
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:      o.rootCAs,
+			Certificates: o.certificates,
+		}
+	}
+	return transport
+}
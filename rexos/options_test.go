@@ -0,0 +1,70 @@
+package rexos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCustomTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			w.Write([]byte(`{"access_token":"service","token_type":"bearer","expires_in":3600}`))
+		case "/projects/1":
+			if r.Header.Get("Authorization") != "Bearer service" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"name":"Machine 1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var mutex sync.Mutex
+	var paths []string
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mutex.Lock()
+		paths = append(paths, req.Method+" "+req.URL.Path)
+		mutex.Unlock()
+		return http.DefaultTransport.RoundTrip(req)
+	})
+
+	client, err := NewClientWithOptions(Config{
+		AccessTokenURL:      server.URL + "/oauth/token",
+		NotApplyServiceUser: true, // the token is refreshed below instead of by the cron job
+	}, WithRoundTripper(transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.HTTPClient() == http.DefaultClient || client.HTTPClient().Transport == nil {
+		t.Fatal("Default client used")
+	}
+	client.config.NotApplyServiceUser = false
+
+	if !client.refreshToken() {
+		t.Fatal("Token refresh failed")
+	}
+	_, body, code, err := client.GetWithServiceUser(testContext(), server.URL+"/projects/1", true)
+	if err != nil || code != http.StatusOK || string(body) != `{"name":"Machine 1"}` {
+		t.Fatal("Request failed", code, err)
+	}
+	if _, code, _ := client.Delete(testContext(), server.URL+"/projects/2"); code != http.StatusNotFound {
+		t.Fatal("Wrong status", code)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(paths) != 3 || paths[0] != "POST /oauth/token" || paths[1] != "GET /projects/1" || paths[2] != "DELETE /projects/2" {
+		t.Fatal("Transport not used", paths)
+	}
+
+	// the interceptor shares the HTTP client of the REXos client
+	interceptor := NewInterceptorWithHTTPClient("", client.HTTPClient())
+	if interceptor.httpClient != client.HTTPClient() {
+		t.Fatal("HTTP client not injected")
+	}
+}
@@ -0,0 +1,242 @@
+package rexos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/roboticeyes/gococo/hal"
+	"github.com/roboticeyes/gococo/status"
+)
+
+// SortDirection is the direction of a sort order
+type SortDirection string
+
+const (
+	// Ascending sorts from the smallest to the largest value
+	Ascending SortDirection = "asc"
+
+	// Descending sorts from the largest to the smallest value
+	Descending SortDirection = "desc"
+)
+
+// SortOrder sorts a collection by a property
+type SortOrder struct {
+	Property  string
+	Direction SortDirection
+}
+
+// Asc sorts ascending by the property
+func Asc(property string) SortOrder {
+	return SortOrder{Property: property, Direction: Ascending}
+}
+
+// Desc sorts descending by the property
+func Desc(property string) SortOrder {
+	return SortOrder{Property: property, Direction: Descending}
+}
+
+// String returns the value of the sort query parameter, e.g. "name,asc"
+func (o SortOrder) String() string {
+	if o.Direction == "" {
+		return o.Property
+	}
+	return o.Property + "," + string(o.Direction)
+}
+
+// PageRequest selects the first page of a collection and the sorting. A size of 0 uses the page
+// size of REXos.
+type PageRequest struct {
+	Page int
+	Size int
+	Sort []SortOrder
+}
+
+// URL returns the collection URL with the page, size and sort query parameters. Sort orders are
+// applied in the given order, e.g. by name and, for equal names, by date.
+func (r PageRequest) URL(collectionURL string) (string, error) {
+	return NewQuery(collectionURL).Page(r.Page, r.Size).Sort(r.Sort...).URL()
+}
+
+// PageMetadata is the page information of a Spring Data REST collection
+type PageMetadata struct {
+	Size          int `json:"size"`
+	TotalElements int `json:"totalElements"`
+	TotalPages    int `json:"totalPages"`
+	Number        int `json:"number"`
+}
+
+// Pager iterates over the items of a Spring Data REST collection across all pages. The pages
+// are fetched lazily by following their next links, iterating stops at the last page, at the
+// first failing request or when the context is canceled:
+//
+//	pager := s.Paginate(ctx, "Projects", projectResourceURL, "projects", PageRequest{Sort: []SortOrder{Asc("name")}})
+//	for pager.Next() {
+//		var project Project
+//		pager.Decode(&project)
+//	}
+//	if ret := pager.Err(); ret != nil {
+//		...
+//	}
+//
+// A Pager must not be used concurrently.
+type Pager struct {
+	service      *Service
+	ctx          context.Context
+	resourceName string
+	rel          string
+	opts         []CallOption
+	next         string // URL of the next page, empty after the last page
+	fetched      bool   // set after the first page has been fetched
+	items        []*hal.Resource
+	index        int
+	page         PageMetadata
+	ret          *status.Status
+}
+
+// Paginate returns a pager over the items of the collection which are embedded with the given
+// relation, e.g. "projects". If the relation is empty the items of all embedded relations are
+// returned.
+func (s *Service) Paginate(ctx context.Context, resourceName, collectionURL, rel string, request PageRequest, opts ...CallOption) *Pager {
+	p := &Pager{service: s, ctx: ctx, resourceName: resourceName, rel: rel, opts: opts, index: -1}
+	first, err := request.URL(collectionURL)
+	if err != nil {
+		p.ret = status.NewStatus([]byte{}, http.StatusBadRequest, "Invalid collection URL "+collectionURL)
+	}
+	p.next = first
+	return p
+}
+
+// Next advances to the next item and returns true if there is one. The next page is fetched
+// when the items of the current page have been consumed.
+func (p *Pager) Next() bool {
+	if p.ret != nil {
+		return false
+	}
+	if err := p.ctx.Err(); err != nil {
+		p.ret = status.NewContextStatus(err, "Listing "+p.resourceName+" aborted")
+		return false
+	}
+
+	p.index++
+	for p.index >= len(p.items) {
+		if p.fetched && p.next == "" {
+			return false
+		}
+		if !p.fetchPage() {
+			return false
+		}
+	}
+	return true
+}
+
+// Item returns the current item
+func (p *Pager) Item() *hal.Resource {
+	if p.index < 0 || p.index >= len(p.items) {
+		return nil
+	}
+	return p.items[p.index]
+}
+
+// Decode decodes the current item into v
+func (p *Pager) Decode(v interface{}) *status.Status {
+	item := p.Item()
+	if item == nil {
+		return status.NewStatus([]byte{}, http.StatusInternalServerError, "No current item")
+	}
+	if err := item.Decode(v); err != nil {
+		return status.NewStatus([]byte{}, http.StatusInternalServerError, "Cannot decode "+p.resourceName+": "+err.Error())
+	}
+	return nil
+}
+
+// All decodes all remaining items into v, which must be a pointer to a slice
+func (p *Pager) All(v interface{}) *status.Status {
+	var items []json.RawMessage
+	for p.Next() {
+		data, _ := p.Item().MarshalJSON()
+		items = append(items, data)
+	}
+	if p.ret != nil {
+		return p.ret
+	}
+	data, _ := json.Marshal(items)
+	if items == nil {
+		data = []byte("[]")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return status.NewStatus([]byte{}, http.StatusInternalServerError, "Cannot decode "+p.resourceName+": "+err.Error())
+	}
+	return nil
+}
+
+// Err returns the status of the failed request or the canceled context which stopped the
+// iteration, nil if all items have been returned
+func (p *Pager) Err() *status.Status {
+	return p.ret
+}
+
+// Page returns the metadata of the current page. The first page is fetched if necessary.
+func (p *Pager) Page() PageMetadata {
+	if !p.fetched && p.ret == nil {
+		p.fetchPage()
+		p.index = -1
+	}
+	return p.page
+}
+
+// TotalElements returns the number of items of the collection as reported by REXos
+func (p *Pager) TotalElements() int {
+	return p.Page().TotalElements
+}
+
+// TotalPages returns the number of pages of the collection as reported by REXos
+func (p *Pager) TotalPages() int {
+	return p.Page().TotalPages
+}
+
+// fetchPage fetches the next page and replaces the current items
+func (p *Pager) fetchPage() bool {
+	body, ret := p.service.GetResource(p.ctx, p.resourceName, p.next, p.opts...)
+	if ret != nil {
+		p.ret = ret
+		return false
+	}
+	r, err := hal.Parse(body)
+	if err != nil {
+		p.ret = status.NewStatus(body, http.StatusBadGateway, "Invalid collection "+p.next+": "+err.Error())
+		return false
+	}
+	var metadata struct {
+		Page PageMetadata `json:"page"`
+	}
+	r.Decode(&metadata)
+
+	p.fetched = true
+	p.page = metadata.Page
+	p.items = pageItems(r, p.rel)
+	p.index = 0
+	p.next = ""
+	if link, ok := r.Link("next"); ok && len(p.items) > 0 {
+		p.next = link.URL()
+	}
+	return true
+}
+
+// pageItems returns the items embedded with the relation, of all relations if it is empty
+func pageItems(r *hal.Resource, rel string) []*hal.Resource {
+	if rel != "" {
+		return r.EmbeddedResources(rel)
+	}
+	rels := make([]string, 0, len(r.Embedded))
+	for k := range r.Embedded {
+		rels = append(rels, k)
+	}
+	sort.Strings(rels)
+	var items []*hal.Resource
+	for _, k := range rels {
+		items = append(items, r.Embedded[k]...)
+	}
+	return items
+}
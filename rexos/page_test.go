@@ -0,0 +1,62 @@
+package rexos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/roboticeyes/gococo/status"
+)
+
+func TestPageRequestURL(t *testing.T) {
+	request := PageRequest{Page: 1, Size: 50, Sort: []SortOrder{Desc("lastUpdated"), Asc("name")}}
+	u, err := request.URL("https://rexos/api/v2/projects?sort=ignored&owner=alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != "https://rexos/api/v2/projects?owner=alice&page=1&size=50&sort=lastUpdated%2Cdesc&sort=name%2Casc" {
+		t.Fatal("Wrong URL", u)
+	}
+}
+
+func TestPagerStopsOnCancel(t *testing.T) {
+	pages := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// an endless collection
+		pages++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `{"_embedded":{"projects":[{"name":"%d"}]},"_links":{"next":{"href":"%s?page=%d"}},"page":{"size":1,"number":%d}}`,
+			page, server.URL, page+1, page)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(testContext())
+	defer cancel()
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	pager := service.Paginate(ctx, "Projects", server.URL, "projects", PageRequest{})
+
+	var names []string
+	for pager.Next() {
+		var project Project
+		if ret := pager.Decode(&project); ret != nil {
+			t.Fatal("Decode failed", ret)
+		}
+		names = append(names, project.Name)
+		if len(names) == 3 {
+			cancel()
+		}
+	}
+	if len(names) != 3 || names[2] != "2" || pages != 3 {
+		t.Fatal("Iteration not stopped", names, pages)
+	}
+	if ret := pager.Err(); ret == nil || ret.InternalStatus.Type != status.TypeRequestCanceled {
+		t.Fatal("Wrong status", ret)
+	}
+	if pager.Page().Number != 2 {
+		t.Fatal("Wrong page", pager.Page())
+	}
+}
@@ -3,6 +3,7 @@ package rexos
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 
 	"github.com/roboticeyes/gococo/event"
 	"github.com/roboticeyes/gococo/status"
@@ -24,32 +25,36 @@ type Project struct {
 
 // TransferProject updates the owner of a project.
 func (s *Service) TransferProject(ctx context.Context, projectResourceURL, userResourceURL string, projectUrn string, newOwner string) (Project, *status.Status) {
-	// find project
-	query := QueryFindByUrn(projectResourceURL, projectUrn)
-	projectResult, ret := s.GetHalResource(ctx, "Project", query)
+	// find project, its self link is patched and must not point to the external gateway
+	var project Project
+	var projectURL string
+	projectTraversal, ret := s.TraverseUrn(projectResourceURL, projectUrn).WithOptions(WithXForwarded(false)).Fetch(ctx)
+	if ret == nil {
+		ret = projectTraversal.Decode(ctx, &project)
+	}
+	if ret == nil {
+		projectURL, ret = projectTraversal.URL(ctx)
+	}
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"projectUrn": projectUrn,
-			"query":      query,
 			"status":     ret,
 		}).Error("Failed to get project")
 
 		ret.Message = "Could not get project. Please make sure you have the correct access rights."
 		return Project{}, ret
 	}
-	var project Project
-	json.Unmarshal(projectResult, &project)
 
 	// find user for given email or userID
-	query = userResourceURL + "/search/findUserIdByEmail?email=" + newOwner
+	query := FindUserIDByEmail(userResourceURL, newOwner).String()
 	userIDResult, ret := s.GetHalResource(ctx, "User", query)
 	if ret != nil {
 		if ret.Code == 404 {
 			// find user for given userID
-			query = userResourceURL + "/search/findUserIdByUsername?username=" + newOwner
+			query = FindUserIDByUsername(userResourceURL, newOwner).String()
 			userIDResult, ret = s.GetHalResource(ctx, "User", query)
 			if ret != nil {
-				log.WithFields(event.Fields{
+				log.WithContext(ctx).WithFields(event.Fields{
 					"projectUrn": projectUrn,
 					"query":      query,
 					"status":     ret,
@@ -59,7 +64,7 @@ func (s *Service) TransferProject(ctx context.Context, projectResourceURL, userR
 				return project, ret
 			}
 		} else {
-			log.WithFields(event.Fields{
+			log.WithContext(ctx).WithFields(event.Fields{
 				"projectUrn": projectUrn,
 				"query":      query,
 				"status":     ret,
@@ -74,26 +79,30 @@ func (s *Service) TransferProject(ctx context.Context, projectResourceURL, userR
 
 	if newOwner == project.Owner {
 		// nothing to do
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"projectUrn": projectUrn,
 		}).Info("Nothing to update.")
 
 		return project, nil
 	}
 
-	project.Owner = owner
-
-	// update project
-	_, ret = s.PatchHalResource(ctx, projectResourceURL, GetSelfLinkFromHal(projectResult), project)
+	// update project, the owner is applied again if the project got modified concurrently
+	_, ret = s.UpdateHalResource(ctx, "Project", projectURL, DefaultUpdateTrials, func(current []byte) (interface{}, *status.Status) {
+		if err := json.Unmarshal(current, &project); err != nil {
+			return nil, status.NewStatus([]byte{}, http.StatusInternalServerError, "Cannot parse project")
+		}
+		project.Owner = owner
+		return project, nil
+	})
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"projectUrn": projectUrn,
 		}).Error("Failed to update owner for project")
 		ret.Message = "Could not update owner for project. Please make sure that you have the correct access rights."
 		return project, ret
 	}
 
-	log.WithFields(event.Fields{
+	log.WithContext(ctx).WithFields(event.Fields{
 		"projectUrn": projectUrn,
 	}).Info("Project owner updated.")
 	return project, nil
@@ -1,26 +1,154 @@
 package rexos
 
+import (
+	"net/url"
+	"strconv"
+)
+
+// Query builds the URL of a REXos resource or search endpoint. All parameter values are URL
+// encoded, so values like emails containing "+" or "&" are passed as they are. A Query is
+// immutable, every method returns a new query.
+type Query struct {
+	base   string
+	params url.Values // parameters which replace those of the base, empty values remove them
+}
+
+// NewQuery returns a query of the resource with the given URL, which may already contain
+// parameters
+func NewQuery(base string) Query {
+	return Query{base: base}
+}
+
+// NewSearch returns a query of the search endpoint with the given name of the resource, e.g.
+// NewSearch(projectResourceURL, "findByUrn")
+func NewSearch(base, name string) Query {
+	return NewQuery(base + "/search/" + name)
+}
+
+// FindByKey returns the query for the resource with the given key
+func FindByKey(base, key string) Query {
+	return NewSearch(base, "findByKey").Param("key", key)
+}
+
+// FindByUrn returns the query for the resource with the given URN
+func FindByUrn(base, urn string) Query {
+	return NewSearch(base, "findByUrn").Param("urn", urn)
+}
+
+// FindAllByParentReferenceAndCategory returns the query for the references with the given
+// parent reference and category
+func FindAllByParentReferenceAndCategory(base, parent, category string) Query {
+	return NewSearch(base, "findAllByParentReferenceAndCategory").Param("parentReference", parent).Param("category", category)
+}
+
+// FindUserIDByEmail returns the query for the ID of the user with the given email
+func FindUserIDByEmail(userResourceURL, email string) Query {
+	return NewSearch(userResourceURL, "findUserIdByEmail").Param("email", email)
+}
+
+// FindUserIDByUsername returns the query for the ID of the user with the given username
+func FindUserIDByUsername(userResourceURL, username string) Query {
+	return NewSearch(userResourceURL, "findUserIdByUsername").Param("username", username)
+}
+
+// FindByUserID returns the query for the user with the given ID
+func FindByUserID(userResourceURL, userID string) Query {
+	return NewSearch(userResourceURL, "findByUserId").Param("userId", userID)
+}
+
+// Param sets the parameter to the given values, which replace the values of the base. Without
+// values the parameter is removed.
+func (q Query) Param(key string, values ...string) Query {
+	c := q.clone()
+	c.params[key] = append([]string{}, values...)
+	return c
+}
+
+// Projection selects the projection of the resources, e.g. "detailed"
+func (q Query) Projection(name string) Query {
+	return q.Param("projection", name)
+}
+
+// Sort sorts the resources by the given orders, the first order has the highest priority
+func (q Query) Sort(orders ...SortOrder) Query {
+	values := make([]string, len(orders))
+	for i, o := range orders {
+		values[i] = o.String()
+	}
+	return q.Param("sort", values...)
+}
+
+// Page selects the page with the given number, starting at 0. A size of 0 uses the page size of
+// REXos.
+func (q Query) Page(page, size int) Query {
+	c := q.Param("page", strconv.Itoa(page))
+	if size > 0 {
+		return c.Param("size", strconv.Itoa(size))
+	}
+	return c.Param("size")
+}
+
+// URL returns the URL of the query
+func (q Query) URL() (string, error) {
+	u, err := url.Parse(q.base)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for k, values := range q.params {
+		if len(values) == 0 {
+			query.Del(k)
+		} else {
+			query[k] = values
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// String returns the URL of the query. If the base is not a valid URL the parameters are appended
+// to it.
+func (q Query) String() string {
+	u, err := q.URL()
+	if err != nil {
+		return q.base + "?" + q.params.Encode()
+	}
+	return u
+}
+
+func (q Query) clone() Query {
+	c := Query{base: q.base, params: make(url.Values, len(q.params)+1)}
+	for k, v := range q.params {
+		c.params[k] = v
+	}
+	return c
+}
+
 // QueryFindByKey generates a FindByKey query
 func QueryFindByKey(base, key string) string {
-	return base + "/search/findByKey?key=" + key
+	return FindByKey(base, key).String()
 }
 
 // QueryFindByUrn generates a FindByUrn query
 func QueryFindByUrn(base, urn string) string {
-	return base + "/search/findByUrn?urn=" + urn
+	return FindByUrn(base, urn).String()
 }
 
 // QueryFindByParentReferenceAndCategory generates a query for getting a parent ref with category
 func QueryFindByParentReferenceAndCategory(base, parent, category string) string {
-	return base + "/search/findAllByParentReferenceAndCategory?parentReference=" + parent + "&category=" + category
+	return FindAllByParentReferenceAndCategory(base, parent, category).String()
 }
 
-// QueryGetPageAndSize generates a query with query parameters page and size
+// QueryGetPageAndSize generates a query with query parameters page and size. The values are
+// not encoded, use NewQuery for untrusted values or Paginate to iterate over all pages of a
+// collection.
 func QueryGetPageAndSize(base, page, size string) string {
 	return base + "?page=" + page + "&size=" + size
 }
 
-// QueryGetPageAndSizeAndSort generates a query with query parameters page and size and sort
+// QueryGetPageAndSizeAndSort generates a query with query parameters page and size and sort.
+// The values are not encoded, so that several sort keys can be given like
+// "name,asc&sort=date,desc". Use NewQuery with Sort for untrusted values.
 func QueryGetPageAndSizeAndSort(base, page, size, sort string) string {
 	return QueryGetPageAndSize(base, page, size) + "&sort=" + sort
 }
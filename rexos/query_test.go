@@ -0,0 +1,46 @@
+package rexos
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestQueryEncoding(t *testing.T) {
+	q := FindUserIDByEmail("https://rexos/api/v2/users", "alice+test@rexos.io&admin=true")
+	u, err := url.Parse(q.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Path != "/api/v2/users/search/findUserIdByEmail" {
+		t.Fatal("Wrong path", u.Path)
+	}
+	if values := u.Query(); len(values) != 1 || values.Get("email") != "alice+test@rexos.io&admin=true" {
+		t.Fatal("Parameter injected", values)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := map[string]string{
+		FindByKey("https://rexos/rexReferences", "a b").String():                                       "https://rexos/rexReferences/search/findByKey?key=a+b",
+		FindByUrn("https://rexos/projects", "robotic-eyes:project:1").Projection("detailed").String():  "https://rexos/projects/search/findByUrn?projection=detailed&urn=robotic-eyes%3Aproject%3A1",
+		FindAllByParentReferenceAndCategory("https://rexos/rexReferences", "1", "track").String():      "https://rexos/rexReferences/search/findAllByParentReferenceAndCategory?category=track&parentReference=1",
+		FindByUserID("https://rexos/users", "alice").String():                                          "https://rexos/users/search/findByUserId?userId=alice",
+		FindUserIDByUsername("https://rexos/users", "bob").String():                                    "https://rexos/users/search/findUserIdByUsername?username=bob",
+		NewQuery("https://rexos/projects").Page(2, 10).Sort(Asc("name"), Desc("owner")).String():       "https://rexos/projects?page=2&size=10&sort=name%2Casc&sort=owner%2Cdesc",
+		NewQuery("https://rexos/projects?size=5&sort=name").Page(1, 0).Sort().Param("x", "1").String(): "https://rexos/projects?page=1&x=1",
+		QueryGetPageAndSizeAndSort("https://rexos/projects", "0", "20", "name,desc"):                   "https://rexos/projects?page=0&size=20&sort=name,desc",
+		QueryGetPageAndSizeAndSort("https://rexos/projects", "0", "20", "name,asc&sort=date,desc"):     "https://rexos/projects?page=0&size=20&sort=name,asc&sort=date,desc",
+	}
+	for q, expected := range tests {
+		if q != expected {
+			t.Errorf("Got %s, expected %s", q, expected)
+		}
+	}
+
+	// queries are immutable
+	base := NewSearch("https://rexos/projects", "findAllByOwner")
+	base.Param("owner", "alice")
+	if s := base.String(); s != "https://rexos/projects/search/findAllByOwner" {
+		t.Fatal("Query modified", s)
+	}
+}
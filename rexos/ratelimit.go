@@ -0,0 +1,183 @@
+package rexos
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned for calls which are rejected because the rate limit of their
+// credential is exceeded
+var ErrRateLimited = errors.New("Rate limit exceeded")
+
+// maxRateLimitBuckets is the number of end-user (or anonymous) buckets which are kept before the least
+// recently used ones are dropped
+const maxRateLimitBuckets = 10000
+
+// RateLimit configures a token bucket. A Rate of 0 disables the limit.
+type RateLimit struct {
+	// Rate is the number of calls per second
+	Rate float64
+
+	// Burst is the number of calls which may be sent at once after the credential was idle
+	Burst int
+}
+
+// RateLimitSettings configures the rate limits of a client
+type RateLimitSettings struct {
+	// ServiceUser is the limit of all calls with the service user credential
+	ServiceUser RateLimit
+
+	// User is the limit of the calls of every single end-user token. Calls without a token are
+	// limited per host.
+	User RateLimit
+
+	// MaxWait is the maximum time a call waits for the limit. Calls which would have to wait
+	// longer, or beyond the deadline of their context, fail with ErrRateLimited.
+	MaxWait time.Duration
+}
+
+// DefaultRateLimitSettings returns the settings which allow 20 calls per second of the service
+// user and 10 calls per second of every end user, waiting up to one second
+func DefaultRateLimitSettings() RateLimitSettings {
+	return RateLimitSettings{
+		ServiceUser: RateLimit{Rate: 20, Burst: 20},
+		User:        RateLimit{Rate: 10, Burst: 10},
+		MaxWait:     time.Second,
+	}
+}
+
+// bucket is a token bucket. The tokens may become negative, which reserves tokens for callers
+// which are waiting.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns the time until it is available
+func (b *bucket) reserve(limit RateLimit, now time.Time) time.Duration {
+	b.refill(limit, now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / limit.Rate * float64(time.Second))
+}
+
+// refill adds the tokens which accrued since the last call
+func (b *bucket) refill(limit RateLimit, now time.Time) {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+	}
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+}
+
+// userBucket is the bucket of an end-user token or of the anonymous calls to a host
+type userBucket struct {
+	bucket
+	key [sha256.Size]byte
+}
+
+// rateLimiter keeps one bucket for the service user, one for every end-user token and one for
+// the anonymous calls to every host
+type rateLimiter struct {
+	settings    RateLimitSettings
+	mutex       sync.Mutex
+	serviceUser bucket
+	maxUsers    int
+	users       map[[sha256.Size]byte]*list.Element
+	lru         *list.List
+}
+
+func newRateLimiter(settings RateLimitSettings) *rateLimiter {
+	return &rateLimiter{
+		settings: settings,
+		maxUsers: maxRateLimitBuckets,
+		users:    make(map[[sha256.Size]byte]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// wait blocks until the call with the given bucket key (see rateLimitKey) may be sent.
+// ErrRateLimited is returned if the call would have to wait longer than allowed, the context
+// error if the context got canceled while waiting.
+func (l *rateLimiter) wait(ctx context.Context, serviceUser bool, key string) error {
+	if l == nil {
+		return nil
+	}
+
+	limit := l.settings.User
+	if serviceUser {
+		limit = l.settings.ServiceUser
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	l.mutex.Lock()
+	b := &l.serviceUser
+	if !serviceUser {
+		b = l.userBucket(key)
+	}
+	now := time.Now()
+	delay := b.reserve(limit, now)
+	deadline, hasDeadline := ctx.Deadline()
+	if delay > l.settings.MaxWait || hasDeadline && now.Add(delay).After(deadline) {
+		// return the token, the call is not sent
+		b.tokens++
+		l.mutex.Unlock()
+		return ErrRateLimited
+	}
+	l.mutex.Unlock()
+
+	if err := sleep(ctx, delay); err != nil {
+		l.mutex.Lock()
+		b.tokens++
+		l.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// userBucket returns the bucket of the key, the caller must hold the lock. The key is hashed,
+// so that a token is not kept in memory longer than necessary. If there are too many buckets,
+// the least recently used one is dropped. It is created again on demand, which only loses its
+// history.
+func (l *rateLimiter) userBucket(bucketKey string) *bucket {
+	key := sha256.Sum256([]byte(bucketKey))
+	if elem, ok := l.users[key]; ok {
+		l.lru.MoveToFront(elem)
+		return &elem.Value.(*userBucket).bucket
+	}
+
+	if l.lru.Len() >= l.maxUsers {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.users, oldest.Value.(*userBucket).key)
+	}
+	b := &userBucket{key: key}
+	l.users[key] = l.lru.PushFront(b)
+	return &b.bucket
+}
+
+// rateLimitKey returns the bucket key of the request, which is its credential. Anonymous
+// requests are keyed by their host, so that they do not throttle unrelated callers.
+func rateLimitKey(req *http.Request) string {
+	if authorization := req.Header.Get("Authorization"); authorization != "" {
+		return authorization
+	}
+	return "\x00" + req.URL.Host
+}
@@ -0,0 +1,114 @@
+package rexos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPerCredential(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service, _ := NewServiceWithOptions(Config{NotApplyServiceUser: true}, WithRateLimit(RateLimitSettings{
+		User:    RateLimit{Rate: 1, Burst: 2},
+		MaxWait: time.Millisecond * 10,
+	}))
+
+	for i := 0; i < 2; i++ {
+		if ret := service.DeleteResource(testContext(), "Project", server.URL); ret != nil {
+			t.Fatal("Call within burst got rejected", ret)
+		}
+	}
+	ret := service.DeleteResource(testContext(), "Project", server.URL)
+	if !ret.IsRateLimited() || ret.Code != http.StatusTooManyRequests {
+		t.Fatal("Call beyond burst has not been rejected", ret)
+	}
+
+	other := context.WithValue(context.Background(), ContextDataKey, ContextData{AccessToken: "Bearer other"})
+	if ret := service.DeleteResource(other, "Project", server.URL); ret != nil {
+		t.Fatal("Limit is shared among credentials", ret)
+	}
+}
+
+func TestRateLimitAnonymousPerHost(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	client, _ := NewClientWithOptions(Config{NotApplyServiceUser: true}, WithRateLimit(RateLimitSettings{
+		User:    RateLimit{Rate: 1, Burst: 1},
+		MaxWait: time.Millisecond * 10,
+	}))
+	anonymous := func(url string) error {
+		_, err := client.Do(testContext(), Request{Method: "DELETE", URL: url, Anonymous: true})
+		return err
+	}
+
+	if err := anonymous(first.URL); err != nil {
+		t.Fatal("Call within burst got rejected", err)
+	}
+	if err := anonymous(first.URL); !errors.Is(err, ErrRateLimited) {
+		t.Fatal("Call beyond burst has not been rejected", err)
+	}
+	if err := anonymous(second.URL); err != nil {
+		t.Fatal("Limit is shared among hosts", err)
+	}
+	if _, err := client.Do(testContext(), Request{Method: "DELETE", URL: first.URL}); err != nil {
+		t.Fatal("Limit is shared with authenticated calls", err)
+	}
+}
+
+func TestRateLimitWaits(t *testing.T) {
+	limiter := newRateLimiter(RateLimitSettings{
+		ServiceUser: RateLimit{Rate: 100, Burst: 1},
+		MaxWait:     time.Second,
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(context.Background(), true, "Bearer service"); err != nil {
+			t.Fatal("Call got rejected", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < time.Millisecond*15 {
+		t.Fatal("Calls have not been delayed", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	limiter.wait(ctx, true, "Bearer service")
+	if err := limiter.wait(ctx, true, "Bearer service"); err != ErrRateLimited {
+		t.Fatal("Wait beyond the deadline has not been rejected", err)
+	}
+}
+
+func TestRateLimitBucketEviction(t *testing.T) {
+	l := newRateLimiter(RateLimitSettings{User: RateLimit{Rate: 1, Burst: 1}})
+	l.maxUsers = 2
+
+	// all buckets are exhausted, so that none of them is idle
+	for _, token := range []string{"Bearer a", "Bearer b", "Bearer a", "Bearer c"} {
+		l.userBucket(token).reserve(l.settings.User, time.Now())
+	}
+	if len(l.users) != 2 || l.lru.Len() != 2 {
+		t.Fatal("Buckets not evicted", len(l.users), l.lru.Len())
+	}
+
+	// the least recently used bucket has been dropped
+	if l.userBucket("Bearer a").tokens >= 0 {
+		t.Fatal("Recently used bucket dropped")
+	}
+	if b := l.userBucket("Bearer b"); b.tokens != 0 || !b.last.IsZero() {
+		t.Fatal("Least recently used bucket kept")
+	}
+}
@@ -0,0 +1,169 @@
+package rexos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// retryPolicyKey is used for storing a retry policy of a single call in the context
+type retryPolicyKey struct{}
+
+// RetryPolicy decides whether a failed request is sent again. Retry is called after every
+// failed trial (starting with 1) with either the response or the error of the HTTP client.
+// It returns the time to wait before the next trial and false if the request must not be
+// retried. The body of a request is replayed automatically, requests with a body which cannot
+// be replayed (see http.Request.GetBody) are never retried.
+type RetryPolicy interface {
+	Retry(trial int, req *http.Request, resp *http.Response, err error) (time.Duration, bool)
+}
+
+// BackoffPolicy retries requests with an exponential backoff. The delay starts with BaseDelay
+// and is doubled for every trial up to MaxDelay. A Retry-After header of the response replaces
+// the calculated delay, it is limited to MaxDelay as well.
+type BackoffPolicy struct {
+	// MaxTrials is the maximum number of trials including the first one
+	MaxTrials int
+
+	// BaseDelay is the delay before the second trial
+	BaseDelay time.Duration
+
+	// MaxDelay is the upper limit for the calculated delay and the one requested by Retry-After
+	MaxDelay time.Duration
+
+	// Jitter is the fraction [0,1] of the delay which is randomized
+	Jitter float64
+
+	// StatusCodes are the response codes which are retried
+	StatusCodes []int
+
+	// Methods are the HTTP methods which are safe to retry. Requests with other methods are
+	// only retried if the connection was refused, because they never reached the server.
+	Methods []string
+}
+
+// NoRetry is a policy which sends every request exactly once
+var NoRetry RetryPolicy = BackoffPolicy{MaxTrials: 1}
+
+// DefaultRetryPolicy returns the policy which is used if neither the client nor the call
+// defines one. Idempotent requests are retried up to MaxTrials times on timeouts, throttling,
+// gateway errors and connection resets.
+func DefaultRetryPolicy() BackoffPolicy {
+	return BackoffPolicy{
+		MaxTrials: MaxTrials,
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  2 * time.Second,
+		Jitter:    0.5,
+		StatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		Methods: []string{"GET", "HEAD", "PUT", "DELETE", "OPTIONS"},
+	}
+}
+
+// Retry implements the RetryPolicy interface
+func (p BackoffPolicy) Retry(trial int, req *http.Request, resp *http.Response, err error) (time.Duration, bool) {
+	if trial >= p.MaxTrials {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return p.delay(trial), true
+		}
+		if !p.safeMethod(req.Method) {
+			return 0, false
+		}
+		if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return p.delay(trial), true
+		}
+		return 0, false
+	}
+
+	if !p.safeMethod(req.Method) || !p.retryStatusCode(resp.StatusCode) {
+		return 0, false
+	}
+	if d, ok := retryAfter(resp); ok {
+		if d > p.MaxDelay {
+			d = p.MaxDelay
+		}
+		return d, true
+	}
+	return p.delay(trial), true
+}
+
+// delay returns the backoff for the given trial including the jitter
+func (p BackoffPolicy) delay(trial int) time.Duration {
+	d := p.BaseDelay << uint(trial-1)
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		j := time.Duration(p.Jitter * float64(d))
+		d = d - j + time.Duration(rand.Int63n(int64(j)+1))
+	}
+	return d
+}
+
+func (p BackoffPolicy) safeMethod(method string) bool {
+	for _, m := range p.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (p BackoffPolicy) retryStatusCode(code int) bool {
+	for _, c := range p.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header which is either given in seconds or as HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// ContextWithRetryPolicy returns a new context which makes all REXos calls using this context
+// apply the given retry policy instead of the one of the client
+func ContextWithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicy returns the policy of the call if there is one, otherwise the one of the client
+func (c *Client) retryPolicy(ctx context.Context) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok && p != nil {
+		return p
+	}
+	if c.retry == nil {
+		return NoRetry
+	}
+	return c.retry
+}
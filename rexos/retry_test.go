@@ -0,0 +1,106 @@
+package rexos
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testContext() context.Context {
+	return context.WithValue(context.Background(), ContextDataKey, ContextData{AccessToken: "Bearer test"})
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		bodies = append(bodies, string(b))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.Methods = append(policy.Methods, "POST")
+	client, _ := NewClientWithOptions(Config{NotApplyServiceUser: true}, WithRetryPolicy(policy))
+
+	_, code, err := client.Post(testContext(), server.URL, strings.NewReader("payload"), "text/plain")
+	if err != nil || code != http.StatusOK {
+		t.Fatal("Request failed", code, err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(bodies) != 3 {
+		t.Fatal("Wrong number of trials", len(bodies))
+	}
+	for _, b := range bodies {
+		if b != "payload" {
+			t.Fatal("Body not replayed", b)
+		}
+	}
+}
+
+func TestNoRetryForUnsafeMethod(t *testing.T) {
+	var trials int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&trials, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{NotApplyServiceUser: true})
+	client.Post(testContext(), server.URL, strings.NewReader("payload"), "text/plain")
+	if n := atomic.LoadInt32(&trials); n != 1 {
+		t.Fatal("POST must not be retried", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("Retry-After", "3")
+	d, ok := retryAfter(resp)
+	if !ok || d != 3*time.Second {
+		t.Fatal("Wrong delay", d)
+	}
+
+	resp.Header.Set("Retry-After", "invalid")
+	if _, ok := retryAfter(resp); ok {
+		t.Fatal("Invalid header accepted")
+	}
+
+	// the requested delay is limited by the policy
+	resp.StatusCode = http.StatusServiceUnavailable
+	resp.Header.Set("Retry-After", "3600")
+	req := httptest.NewRequest("GET", "http://rexos/projects", nil)
+	if d, ok := DefaultRetryPolicy().Retry(1, req, resp, nil); !ok || d != 2*time.Second {
+		t.Fatal("Delay not limited", d, ok)
+	}
+}
+
+func TestPerCallRetryPolicy(t *testing.T) {
+	var trials int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&trials, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{NotApplyServiceUser: true})
+	client.Get(ContextWithRetryPolicy(testContext(), NoRetry), server.URL, true)
+	if n := atomic.LoadInt32(&trials); n != 1 {
+		t.Fatal("Policy of the call not applied", n)
+	}
+}
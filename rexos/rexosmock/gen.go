@@ -0,0 +1,152 @@
+//go:build ignore
+// +build ignore
+
+// gen generates the methods of the Mock from the interfaces in rexos/accessor.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+const (
+	source = "../accessor.go"
+	target = "mock_gen.go"
+)
+
+const header = `// Code generated by go run gen.go; DO NOT EDIT.
+
+package rexosmock
+
+import (
+	"context"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roboticeyes/gococo/rexos"
+	"github.com/roboticeyes/gococo/status"
+)
+`
+
+func main() {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, source, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			for _, m := range iface.Methods.List {
+				// embedded interfaces are generated by their own declaration
+				if len(m.Names) == 0 {
+					continue
+				}
+				writeMethod(&buf, fset, ts.Name.Name, m.Names[0].Name, m.Type.(*ast.FuncType))
+			}
+		}
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(target, out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeMethod(buf *bytes.Buffer, fset *token.FileSet, iface, name string, fn *ast.FuncType) {
+	var params, args []string
+	for i, p := range fn.Params.List {
+		typ := typeString(fset, p.Type)
+		names := p.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+		}
+		for _, n := range names {
+			params = append(params, n.Name+" "+typ)
+			if typ != "context.Context" {
+				args = append(args, n.Name)
+			}
+		}
+	}
+
+	var types []string
+	for _, r := range fn.Results.List {
+		types = append(types, typeString(fset, r.Type))
+	}
+
+	fmt.Fprintf(buf, "\n// %s records the call and returns the programmed results, see rexos.%s\n", name, iface)
+	fmt.Fprintf(buf, "func (m *Mock) %s(%s) (%s) {\n", name, strings.Join(params, ", "), strings.Join(types, ", "))
+	fmt.Fprintf(buf, "\tres := m.called(%q, %d", name, len(types))
+	if len(args) > 0 {
+		fmt.Fprintf(buf, ", %s", strings.Join(args, ", "))
+	}
+	buf.WriteString(")\n")
+	var results []string
+	for i, typ := range types {
+		fmt.Fprintf(buf, "\tvar r%d %s\n\tif v := res.get(%d); v != nil {\n\t\tr%d = v.(%s)\n\t}\n", i, typ, i, i, typ)
+		results = append(results, fmt.Sprintf("r%d", i))
+	}
+	fmt.Fprintf(buf, "\treturn %s\n}\n", strings.Join(results, ", "))
+}
+
+// typeString prints the type, qualifying the types declared in package rexos
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, qualify(expr)); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+func qualify(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(e.Name) {
+			return &ast.SelectorExpr{X: ast.NewIdent("rexos"), Sel: ast.NewIdent(e.Name)}
+		}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(e.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: qualify(e.Elt)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: qualify(e.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(e.Key), Value: qualify(e.Value)}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: qualifyFields(e.Params), Results: qualifyFields(e.Results)}
+	}
+	return expr
+}
+
+func qualifyFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	q := &ast.FieldList{}
+	for _, f := range fields.List {
+		q.List = append(q.List, &ast.Field{Names: f.Names, Type: qualify(f.Type)})
+	}
+	return q
+}
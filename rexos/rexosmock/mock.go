@@ -0,0 +1,147 @@
+// Package rexosmock provides a test double for rexos.RexOSAccessor and its role interfaces. The
+// Mock records all calls and returns programmed results, without programmed results it acts as a
+// stub whose methods succeed with zero values.
+//
+// The methods of the Mock are generated from the interfaces in rexos/accessor.go, run go generate
+// after changing them.
+package rexosmock
+
+//go:generate go run gen.go
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/roboticeyes/gococo/rexos"
+	"github.com/roboticeyes/gococo/status"
+)
+
+var _ rexos.RexOSAccessor = (*Mock)(nil)
+
+// Call is a recorded call of a method. The arguments do not contain the context, variadic
+// arguments are recorded as slice.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// ResultFunc computes the results of a call from its arguments
+type ResultFunc func(args ...interface{}) []interface{}
+
+// Mock implements rexos.RexOSAccessor. All methods are safe for concurrent use.
+type Mock struct {
+	mutex    sync.Mutex
+	calls    []Call
+	results  map[string][][]interface{}
+	funcs    map[string]ResultFunc
+	fallback *status.Status
+}
+
+// New returns a mock without programmed results
+func New() *Mock {
+	return &Mock{
+		results: make(map[string][][]interface{}),
+		funcs:   make(map[string]ResultFunc),
+	}
+}
+
+// NewFailingStub returns a mock whose methods return the given status unless other results are
+// programmed, e.g. for testing the error handling of a composite service
+func NewFailingStub(ret *status.Status) *Mock {
+	m := New()
+	m.fallback = ret
+	return m
+}
+
+// Return programs the results of the next call of the method. The results must have the types of
+// the results of the method, nil is the zero value. If Return is called several times the results
+// are returned in order, the last results are repeated for all further calls.
+func (m *Mock) Return(method string, results ...interface{}) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.results[method] = append(m.results[method], results)
+	return m
+}
+
+// ReturnFunc programs a function which computes the results of all calls of the method, e.g.
+// depending on the URL. It takes precedence over results programmed by Return.
+func (m *Mock) ReturnFunc(method string, f ResultFunc) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.funcs[method] = f
+	return m
+}
+
+// Calls returns all recorded calls in the order they have been made
+func (m *Mock) Calls() []Call {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+// CallsTo returns the recorded calls of the method
+func (m *Mock) CallsTo(method string) []Call {
+	var matching []Call
+	for _, c := range m.Calls() {
+		if c.Method == method {
+			matching = append(matching, c)
+		}
+	}
+	return matching
+}
+
+// AssertCalled fails the test if the method has not been called the given number of times
+func (m *Mock) AssertCalled(t testing.TB, method string, count int) {
+	t.Helper()
+	if n := len(m.CallsTo(method)); n != count {
+		t.Fatalf("Expected %d calls of %s, got %d", count, method, n)
+	}
+}
+
+// Reset removes all recorded calls and programmed results
+func (m *Mock) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = nil
+	m.results = make(map[string][][]interface{})
+	m.funcs = make(map[string]ResultFunc)
+}
+
+// called records the call and returns its n results. The last result of every method is the
+// status, which is set to the fallback if no results are programmed.
+func (m *Mock) called(method string, n int, args ...interface{}) results {
+	m.mutex.Lock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+	f := m.funcs[method]
+	var r []interface{}
+	if programmed := m.results[method]; f == nil && len(programmed) > 0 {
+		r = programmed[0]
+		if len(programmed) > 1 {
+			m.results[method] = programmed[1:]
+		}
+	} else if f == nil && m.fallback != nil {
+		r = make([]interface{}, n)
+		r[n-1] = m.fallback
+	}
+	m.mutex.Unlock()
+
+	if f != nil {
+		r = f(args...)
+	}
+	if len(r) > n {
+		panic(fmt.Sprintf("rexosmock: %d results programmed for %s, which returns %d", len(r), method, n))
+	}
+	return r
+}
+
+// results are the programmed results of a call
+type results []interface{}
+
+// get returns the i-th result, nil if it has not been programmed
+func (r results) get(i int) interface{} {
+	if i < len(r) {
+		return r[i]
+	}
+	return nil
+}
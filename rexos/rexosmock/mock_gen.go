@@ -0,0 +1,662 @@
+// Code generated by go run gen.go; DO NOT EDIT.
+
+package rexosmock
+
+import (
+	"context"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/roboticeyes/gococo/rexos"
+	"github.com/roboticeyes/gococo/status"
+)
+
+// GetResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) GetResource(ctx context.Context, resourceName string, url string, opts ...rexos.CallOption) ([]byte, *status.Status) {
+	res := m.called("GetResource", 2, resourceName, url, opts)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) CreateResource(ctx context.Context, resourceName string, url string, r interface{}, opts ...rexos.CallOption) ([]byte, *status.Status) {
+	res := m.called("CreateResource", 2, resourceName, url, r, opts)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// PatchResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) PatchResource(ctx context.Context, resourceName string, url string, r interface{}, opts ...rexos.CallOption) ([]byte, *status.Status) {
+	res := m.called("PatchResource", 2, resourceName, url, r, opts)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// ReplaceResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) ReplaceResource(ctx context.Context, resourceName string, url string, r interface{}, opts ...rexos.CallOption) ([]byte, *status.Status) {
+	res := m.called("ReplaceResource", 2, resourceName, url, r, opts)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DeleteResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) DeleteResource(ctx context.Context, resourceName string, url string, opts ...rexos.CallOption) *status.Status {
+	res := m.called("DeleteResource", 1, resourceName, url, opts)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// SendResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) SendResource(ctx context.Context, resourceName string, r rexos.Request, opts ...rexos.CallOption) ([]byte, *status.Status) {
+	res := m.called("SendResource", 2, resourceName, r, opts)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) GetHalResource(ctx context.Context, resourceName string, url string) ([]byte, *status.Status) {
+	res := m.called("GetHalResource", 2, resourceName, url)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetHalResourceNoXF records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) GetHalResourceNoXF(ctx context.Context, resourceName string, url string) ([]byte, *status.Status) {
+	res := m.called("GetHalResourceNoXF", 2, resourceName, url)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetHalResourceWithServiceUser records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) GetHalResourceWithServiceUser(ctx context.Context, resourceName string, url string) ([]byte, *status.Status) {
+	res := m.called("GetHalResourceWithServiceUser", 2, resourceName, url)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetHalResourceWithServiceUserNoXF records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) GetHalResourceWithServiceUserNoXF(ctx context.Context, resourceName string, url string) ([]byte, *status.Status) {
+	res := m.called("GetHalResourceWithServiceUserNoXF", 2, resourceName, url)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) CreateHalResource(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("CreateHalResource", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateHalResourceWithXF records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) CreateHalResourceWithXF(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("CreateHalResourceWithXF", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateHalResourceWithServiceUser records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) CreateHalResourceWithServiceUser(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("CreateHalResourceWithServiceUser", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateHalResourceWithServiceUserWithXF records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) CreateHalResourceWithServiceUserWithXF(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("CreateHalResourceWithServiceUserWithXF", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// PatchHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) PatchHalResource(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("PatchHalResource", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// PatchHalResourceWithXF records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) PatchHalResourceWithXF(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("PatchHalResourceWithXF", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// PatchHalResourceWithServiceUser records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) PatchHalResourceWithServiceUser(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("PatchHalResourceWithServiceUser", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// PatchHalResourceWithServiceUserWithXF records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) PatchHalResourceWithServiceUserWithXF(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("PatchHalResourceWithServiceUserWithXF", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// ReplaceHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) ReplaceHalResource(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("ReplaceHalResource", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// ReplaceHalResourceWithServiceUser records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) ReplaceHalResourceWithServiceUser(ctx context.Context, resourceName string, url string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("ReplaceHalResourceWithServiceUser", 2, resourceName, url, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DeleteHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) DeleteHalResource(ctx context.Context, resourceName string, url string) *status.Status {
+	res := m.called("DeleteHalResource", 1, resourceName, url)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// LinkHalResources records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) LinkHalResources(ctx context.Context, resourceName string, associationURL string, links ...string) *status.Status {
+	res := m.called("LinkHalResources", 1, resourceName, associationURL, links)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// AddHalResourceLinks records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) AddHalResourceLinks(ctx context.Context, resourceName string, associationURL string, links ...string) *status.Status {
+	res := m.called("AddHalResourceLinks", 1, resourceName, associationURL, links)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// HalResourceExists records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) HalResourceExists(ctx context.Context, resourceName string, url string, opts ...rexos.CallOption) (bool, *status.Status) {
+	res := m.called("HalResourceExists", 2, resourceName, url, opts)
+	var r0 bool
+	if v := res.get(0); v != nil {
+		r0 = v.(bool)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetVersionedHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) GetVersionedHalResource(ctx context.Context, resourceName string, url string) ([]byte, string, *status.Status) {
+	res := m.called("GetVersionedHalResource", 3, resourceName, url)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 string
+	if v := res.get(1); v != nil {
+		r1 = v.(string)
+	}
+	var r2 *status.Status
+	if v := res.get(2); v != nil {
+		r2 = v.(*status.Status)
+	}
+	return r0, r1, r2
+}
+
+// PatchVersionedHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) PatchVersionedHalResource(ctx context.Context, resourceName string, url string, version string, r interface{}) ([]byte, *status.Status) {
+	res := m.called("PatchVersionedHalResource", 2, resourceName, url, version, r)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// UpdateHalResource records the call and returns the programmed results, see rexos.ResourceAccessor
+func (m *Mock) UpdateHalResource(ctx context.Context, resourceName string, url string, trials int, mutate rexos.MutateFunc) ([]byte, *status.Status) {
+	res := m.called("UpdateHalResource", 2, resourceName, url, trials, mutate)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DownloadFileContent records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) DownloadFileContent(ctx context.Context, downloadURL string, authenticate bool) ([]byte, *status.Status) {
+	res := m.called("DownloadFileContent", 2, downloadURL, authenticate)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DownloadFileContentVerified records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) DownloadFileContentVerified(ctx context.Context, downloadURL string, authenticate bool) ([]byte, *status.Status) {
+	res := m.called("DownloadFileContentVerified", 2, downloadURL, authenticate)
+	var r0 []byte
+	if v := res.get(0); v != nil {
+		r0 = v.([]byte)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DownloadFileStream records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) DownloadFileStream(ctx context.Context, downloadURL string, authenticate bool) (*rexos.FileDownload, *status.Status) {
+	res := m.called("DownloadFileStream", 2, downloadURL, authenticate)
+	var r0 *rexos.FileDownload
+	if v := res.get(0); v != nil {
+		r0 = v.(*rexos.FileDownload)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DownloadFileStreamVerified records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) DownloadFileStreamVerified(ctx context.Context, downloadURL string, authenticate bool) (*rexos.FileDownload, *status.Status) {
+	res := m.called("DownloadFileStreamVerified", 2, downloadURL, authenticate)
+	var r0 *rexos.FileDownload
+	if v := res.get(0); v != nil {
+		r0 = v.(*rexos.FileDownload)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetFileWithServiceUser records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) GetFileWithServiceUser(ctx context.Context, c *gin.Context, url string) *status.Status {
+	res := m.called("GetFileWithServiceUser", 1, c, url)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// GetProjectFileHash records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) GetProjectFileHash(ctx context.Context, projectFileURL string) (string, *status.Status) {
+	res := m.called("GetProjectFileHash", 2, projectFileURL)
+	var r0 string
+	if v := res.get(0); v != nil {
+		r0 = v.(string)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// UploadFileContent records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) UploadFileContent(ctx context.Context, uploadURL string, downloadURL string, authenticate bool) *status.Status {
+	res := m.called("UploadFileContent", 1, uploadURL, downloadURL, authenticate)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// UploadMultipartFile records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) UploadMultipartFile(ctx context.Context, fileName string, uploadURL string, data io.Reader) *status.Status {
+	res := m.called("UploadMultipartFile", 1, fileName, uploadURL, data)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// UploadFile records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) UploadFile(ctx context.Context, fileName string, uploadURL string, data []byte) *status.Status {
+	res := m.called("UploadFile", 1, fileName, uploadURL, data)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// UploadFileIfChanged records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) UploadFileIfChanged(ctx context.Context, fileName string, uploadURL string, data []byte) (bool, *status.Status) {
+	res := m.called("UploadFileIfChanged", 2, fileName, uploadURL, data)
+	var r0 bool
+	if v := res.get(0); v != nil {
+		r0 = v.(bool)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// UploadMultipart records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) UploadMultipart(ctx context.Context, uploadURL string, upload rexos.MultipartUpload) *status.Status {
+	res := m.called("UploadMultipart", 1, uploadURL, upload)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// ResumeUpload records the call and returns the programmed results, see rexos.FileAccessor
+func (m *Mock) ResumeUpload(ctx context.Context, session *rexos.UploadSession, content io.ReaderAt, progress func(rexos.UploadSession)) *status.Status {
+	res := m.called("ResumeUpload", 1, session, content, progress)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// GetShare records the call and returns the programmed results, see rexos.ShareAccessor
+func (m *Mock) GetShare(ctx context.Context, projectResourceURL string, userResourceURL string, projectUrn string) (rexos.Share, *status.Status) {
+	res := m.called("GetShare", 2, projectResourceURL, userResourceURL, projectUrn)
+	var r0 rexos.Share
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.Share)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// UpdateShare records the call and returns the programmed results, see rexos.ShareAccessor
+func (m *Mock) UpdateShare(ctx context.Context, projectResourceURL string, userResourceURL string, projectUrn string, share rexos.Share) (rexos.Share, *status.Status) {
+	res := m.called("UpdateShare", 2, projectResourceURL, userResourceURL, projectUrn, share)
+	var r0 rexos.Share
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.Share)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateOrUpdateUserShare records the call and returns the programmed results, see rexos.ShareAccessor
+func (m *Mock) CreateOrUpdateUserShare(ctx context.Context, projectResourceURL string, userResourceURL string, projectUrn string, userShare rexos.UserShare) (rexos.UserShare, *status.Status) {
+	res := m.called("CreateOrUpdateUserShare", 2, projectResourceURL, userResourceURL, projectUrn, userShare)
+	var r0 rexos.UserShare
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.UserShare)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// DeleteUserShare records the call and returns the programmed results, see rexos.ShareAccessor
+func (m *Mock) DeleteUserShare(ctx context.Context, resourceURL string, projectUrn string, userID string) *status.Status {
+	res := m.called("DeleteUserShare", 1, resourceURL, projectUrn, userID)
+	var r0 *status.Status
+	if v := res.get(0); v != nil {
+		r0 = v.(*status.Status)
+	}
+	return r0
+}
+
+// GetUserInformation records the call and returns the programmed results, see rexos.UserAccessor
+func (m *Mock) GetUserInformation(ctx context.Context, resourceURL string) (rexos.UserInformation, *status.Status) {
+	res := m.called("GetUserInformation", 2, resourceURL)
+	var r0 rexos.UserInformation
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.UserInformation)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetCurrentUser records the call and returns the programmed results, see rexos.UserAccessor
+func (m *Mock) GetCurrentUser(ctx context.Context, resourceURL string) (rexos.UserInformation, string, *status.Status) {
+	res := m.called("GetCurrentUser", 3, resourceURL)
+	var r0 rexos.UserInformation
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.UserInformation)
+	}
+	var r1 string
+	if v := res.get(1); v != nil {
+		r1 = v.(string)
+	}
+	var r2 *status.Status
+	if v := res.get(2); v != nil {
+		r2 = v.(*status.Status)
+	}
+	return r0, r1, r2
+}
+
+// GetUserStatistics records the call and returns the programmed results, see rexos.UserAccessor
+func (m *Mock) GetUserStatistics(ctx context.Context, resourceURL string) (rexos.UserStatistics, *status.Status) {
+	res := m.called("GetUserStatistics", 2, resourceURL)
+	var r0 rexos.UserStatistics
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.UserStatistics)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// GetUserLicenses records the call and returns the programmed results, see rexos.UserAccessor
+func (m *Mock) GetUserLicenses(ctx context.Context, resourceURL string) (rexos.UserLicenses, *status.Status) {
+	res := m.called("GetUserLicenses", 2, resourceURL)
+	var r0 rexos.UserLicenses
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.UserLicenses)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// TransferProject records the call and returns the programmed results, see rexos.ProjectAccessor
+func (m *Mock) TransferProject(ctx context.Context, projectResourceURL string, userResourceURL string, projectUrn string, newOwner string) (rexos.Project, *status.Status) {
+	res := m.called("TransferProject", 2, projectResourceURL, userResourceURL, projectUrn, newOwner)
+	var r0 rexos.Project
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.Project)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
+
+// CreateProjectInvitation records the call and returns the programmed results, see rexos.ProjectAccessor
+func (m *Mock) CreateProjectInvitation(ctx context.Context, projectUrn string, projectInvitation rexos.ProjectInvitation, projectResourceURL string, userResourceURL string, invitationURL string, rexCodesResourceURL string) (rexos.ProjectInvitation, *status.Status) {
+	res := m.called("CreateProjectInvitation", 2, projectUrn, projectInvitation, projectResourceURL, userResourceURL, invitationURL, rexCodesResourceURL)
+	var r0 rexos.ProjectInvitation
+	if v := res.get(0); v != nil {
+		r0 = v.(rexos.ProjectInvitation)
+	}
+	var r1 *status.Status
+	if v := res.get(1); v != nil {
+		r1 = v.(*status.Status)
+	}
+	return r0, r1
+}
@@ -0,0 +1,91 @@
+package rexosmock
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/roboticeyes/gococo/rexos"
+	"github.com/roboticeyes/gococo/status"
+)
+
+// projectName is a composite function which only depends on a role interface
+func projectName(ctx context.Context, resources rexos.ResourceAccessor, url string) (string, *status.Status) {
+	body, ret := resources.GetHalResource(ctx, "Project", url)
+	if ret != nil {
+		return "", ret
+	}
+	return string(body), nil
+}
+
+func TestMock(t *testing.T) {
+	m := New()
+	m.Return("GetHalResource", []byte("first"))
+	m.Return("GetHalResource", []byte("second"))
+
+	for _, expected := range []string{"first", "second", "second"} {
+		name, ret := projectName(context.Background(), m, "https://rexos/projects/1")
+		if ret != nil || name != expected {
+			t.Fatal("Wrong result", name, ret)
+		}
+	}
+	m.AssertCalled(t, "GetHalResource", 3)
+	if args := m.CallsTo("GetHalResource")[0].Args; len(args) != 2 || args[0] != "Project" || args[1] != "https://rexos/projects/1" {
+		t.Fatal("Wrong arguments", args)
+	}
+
+	// unprogrammed methods are stubs which succeed with zero values
+	exists, ret := m.HalResourceExists(context.Background(), "Project", "https://rexos/projects/1")
+	if exists || ret != nil {
+		t.Fatal("Wrong stub result", exists, ret)
+	}
+	if ret := m.LinkHalResources(context.Background(), "Project", "https://rexos/projects/1/users", "a", "b"); ret != nil {
+		t.Fatal("Wrong stub result", ret)
+	}
+	if links := m.CallsTo("LinkHalResources")[0].Args[2].([]string); len(links) != 2 {
+		t.Fatal("Variadic arguments not recorded", links)
+	}
+	if len(m.Calls()) != 5 {
+		t.Fatal("Wrong number of calls", m.Calls())
+	}
+
+	m.Reset()
+	if len(m.Calls()) != 0 {
+		t.Fatal("Calls not reset")
+	}
+}
+
+func TestReturnFunc(t *testing.T) {
+	m := New()
+	m.Return("GetUserStatistics", rexos.UserStatistics{}, status.NewStatus(nil, http.StatusInternalServerError, "Ignored"))
+	m.ReturnFunc("GetUserStatistics", func(args ...interface{}) []interface{} {
+		if args[0] == "https://rexos/users" {
+			return []interface{}{rexos.UserStatistics{NumberOfProjects: 3}}
+		}
+		return []interface{}{nil, status.NewStatus(nil, http.StatusNotFound, "Not found")}
+	})
+
+	statistics, ret := m.GetUserStatistics(context.Background(), "https://rexos/users")
+	if ret != nil || statistics.NumberOfProjects != 3 {
+		t.Fatal("Wrong result", statistics, ret)
+	}
+	if _, ret := m.GetUserStatistics(context.Background(), "https://other/users"); ret == nil || ret.Code != http.StatusNotFound {
+		t.Fatal("Wrong status", ret)
+	}
+}
+
+func TestFailingStub(t *testing.T) {
+	unavailable := status.NewStatus(nil, http.StatusServiceUnavailable, "REXos is down")
+	m := NewFailingStub(unavailable)
+	m.Return("GetCurrentUser", rexos.UserInformation{LastLogin: "yesterday"}, "alice")
+
+	if _, ret := m.GetShare(context.Background(), "https://rexos/projects", "https://rexos/users", "urn"); ret != unavailable {
+		t.Fatal("Fallback status not returned", ret)
+	}
+	if ret := m.DeleteHalResource(context.Background(), "Project", "https://rexos/projects/1"); ret != unavailable {
+		t.Fatal("Fallback status not returned", ret)
+	}
+	if user, userID, ret := m.GetCurrentUser(context.Background(), "https://rexos/users"); ret != nil || user.LastLogin != "yesterday" || userID != "alice" {
+		t.Fatal("Programmed results not returned", user, userID, ret)
+	}
+}
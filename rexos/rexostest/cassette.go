@@ -0,0 +1,303 @@
+package rexostest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/roboticeyes/gococo/rexos"
+)
+
+// CassetteMode defines whether a cassette records or replays exchanges
+type CassetteMode int
+
+const (
+	// ModeReplay serves the recorded exchanges without sending any request
+	ModeReplay CassetteMode = iota
+
+	// ModeRecord sends the requests and records the exchanges
+	ModeRecord
+)
+
+// redacted replaces credentials in recorded exchanges
+const redacted = "REDACTED"
+
+// ErrNoInteraction is returned in replay mode for requests which have not been recorded
+var ErrNoInteraction = errors.New("No recorded interaction matches the request")
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+`)
+	tokenPattern = regexp.MustCompile(`("(?:access_token|refresh_token|id_token)"\s*:\s*)"[^"]*"`)
+
+	// recordedHeaders are the response header fields which are kept in the cassette
+	recordedHeaders = []string{"Content-Type", "Content-Disposition", "ETag", "Last-Modified", "Location", "Retry-After"}
+)
+
+// Interaction is a recorded exchange
+type Interaction struct {
+	Request  RecordedExchange `json:"request"`
+	Response RecordedExchange `json:"response"`
+
+	replayed bool
+}
+
+// RecordedExchange is the redacted request or response of an interaction
+type RecordedExchange struct {
+	Method       string      `json:"method,omitempty"`
+	URL          string      `json:"url,omitempty"`
+	Status       int         `json:"status,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"bodyEncoding,omitempty"`
+}
+
+// Cassette records the exchanges of a rexos.Client into a file and replays them offline. Access
+// tokens and email addresses are redacted before they are written. Emails are replaced by
+// stable pseudonyms, so that requests which contain them still match when they are replayed.
+//
+// Requests are matched by method, URL and body. JSON bodies are compared independent of the order
+// of their fields, multipart bodies are not compared since their boundaries are random. Every
+// recorded interaction is replayed once, in the order of recording.
+type Cassette struct {
+	path         string
+	mode         CassetteMode
+	mutex        sync.Mutex
+	interactions []*Interaction
+}
+
+// OpenCassette opens the cassette at the given path. In replay mode the cassette is loaded from
+// the file, in record mode a new cassette is started which is written by Save.
+func OpenCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	if mode == ModeRecord {
+		return c, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.interactions); err != nil {
+		return nil, fmt.Errorf("Cannot parse cassette %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// CassetteModeFromEnv returns ModeRecord if the environment variable REXOS_CASSETTE is set to
+// "record", ModeReplay otherwise. This allows re-recording all cassettes of a test suite against
+// a staging gateway without code changes.
+func CassetteModeFromEnv() CassetteMode {
+	if os.Getenv("REXOS_CASSETTE") == "record" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Option returns the client option which makes a rexos.Client use the cassette
+func (c *Cassette) Option() rexos.ClientOption {
+	return rexos.WithMiddleware(c.Middleware())
+}
+
+// Middleware returns the middleware which records or replays the requests of the client. In
+// record mode the requests are passed to the next round tripper.
+func (c *Cassette) Middleware() rexos.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return rexos.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if c.mode == ModeRecord {
+				return c.record(next, req)
+			}
+			return c.replay(req)
+		})
+	}
+}
+
+// Save writes the recorded interactions to the file of the cassette. Nothing is written in
+// replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	c.mutex.Lock()
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0644)
+}
+
+// Interactions returns the number of interactions of the cassette
+func (c *Cassette) Interactions() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.interactions)
+}
+
+func (c *Cassette) record(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: RecordedExchange{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+		},
+		Response: RecordedExchange{
+			Status: resp.StatusCode,
+			Header: http.Header{},
+		},
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		interaction.Request.Header = http.Header{"Content-Type": {contentType}}
+	}
+	interaction.Request.Body, interaction.Request.BodyEncoding = encodeBody(redact(reqBody))
+	interaction.Response.Body, interaction.Response.BodyEncoding = encodeBody(redact(respBody))
+	for _, k := range recordedHeaders {
+		if v := resp.Header.Values(k); len(v) > 0 {
+			interaction.Response.Header[k] = v
+		}
+	}
+
+	c.mutex.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mutex.Unlock()
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	method, u := req.Method, redactURL(req.URL)
+	body := redact(reqBody)
+	multipart := strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, interaction := range c.interactions {
+		r := interaction.Request
+		if interaction.replayed || r.Method != method || r.URL != u {
+			continue
+		}
+		if !multipart && !equalBodies(decodeBody(r.Body, r.BodyEncoding), body) {
+			continue
+		}
+
+		interaction.replayed = true
+		respBody := decodeBody(interaction.Response.Body, interaction.Response.BodyEncoding)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, method, u)
+}
+
+// readRequestBody reads the body of the request and replaces it by a copy
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// redactURL returns the URL with redacted query values and sorted query parameters
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	query := u.Query()
+	for k, values := range query {
+		for i, v := range values {
+			values[i] = string(redact([]byte(v)))
+		}
+		query[k] = values
+	}
+	redactedURL.RawQuery = query.Encode()
+	redactedURL.Path = string(redact([]byte(u.Path)))
+	redactedURL.RawPath = ""
+	return redactedURL.String()
+}
+
+// redact removes access tokens and replaces email addresses by stable pseudonyms
+func redact(data []byte) []byte {
+	data = tokenPattern.ReplaceAll(data, []byte(`$1"`+redacted+`"`))
+	data = jwtPattern.ReplaceAll(data, []byte(redacted))
+	return emailPattern.ReplaceAllFunc(data, func(email []byte) []byte {
+		h := sha256.Sum256(bytes.ToLower(email))
+		return []byte("user-" + hex.EncodeToString(h[:4]) + "@example.invalid")
+	})
+}
+
+// equalBodies compares the bodies, JSON bodies independent of the order of their fields
+func equalBodies(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var ja, jb interface{}
+	if json.Unmarshal(a, &ja) != nil || json.Unmarshal(b, &jb) != nil {
+		return false
+	}
+	ca, _ := json.Marshal(ja)
+	cb, _ := json.Marshal(jb)
+	return bytes.Equal(ca, cb)
+}
+
+// encodeBody returns the body as string, binary bodies are encoded as base64
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body, encoding string) []byte {
+	if encoding == "base64" {
+		b, _ := base64.StdEncoding.DecodeString(body)
+		return b
+	}
+	return []byte(body)
+}
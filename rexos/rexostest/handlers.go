@@ -0,0 +1,646 @@
+package rexostest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPageSize is the page size of collections if none is requested
+const defaultPageSize = 20
+
+// resource is a stored HAL resource. Its version is reported as ETag and checked for If-Match.
+type resource struct {
+	id      string
+	data    map[string]interface{}
+	version int
+}
+
+func newResource(id string, data map[string]interface{}) *resource {
+	return &resource{id: id, data: data}
+}
+
+// decode converts the data of the resource to the given value
+func (r *resource) decode(v interface{}) {
+	b, _ := json.Marshal(r.data)
+	json.Unmarshal(b, v)
+}
+
+func (r *resource) etag() string {
+	return `"` + itoa(r.version) + `"`
+}
+
+// request is a request which has been authenticated
+type request struct {
+	*http.Request
+	body   []byte
+	caller string // user ID, empty for the service user
+}
+
+// route dispatches the request to the handler of the resource, the caller must hold the lock
+func (s *Server) route(w http.ResponseWriter, r *request) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, BasePath), "/"), "/")
+	switch segments[0] {
+	case "projects":
+		s.serveProjects(w, r, segments[1:])
+	case "rexReferences":
+		s.serveReferences(w, r, segments[1:])
+	case "users":
+		s.serveUsers(w, r, segments[1:])
+	case "invitations":
+		s.serveInvitations(w, r, segments[1:])
+	default:
+		writeError(w, http.StatusNotFound, "Unknown resource "+segments[0])
+	}
+}
+
+func (s *Server) serveProjects(w http.ResponseWriter, r *request, segments []string) {
+	switch {
+	case len(segments) == 0:
+		s.serveCollection(w, r, "projects", s.projects, s.renderProject, func(data map[string]interface{}) {
+			data["urn"] = "robotic-eyes:project:" + itoa(s.nextID)
+			if data["owner"] == nil {
+				data["owner"] = r.caller
+			}
+		})
+
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "findByUrn":
+		s.serveSearch(w, r, s.projects, s.renderProject, "urn", r.URL.Query().Get("urn"))
+
+	case len(segments) == 1 && segments[0] == "statisticsByUser":
+		if !s.allowed(w, r, r.URL.Query().Get("userId")) {
+			return
+		}
+		writeJSON(w, http.StatusOK, s.statistics[r.URL.Query().Get("userId")])
+
+	case len(segments) == 1:
+		s.serveResource(w, r, s.projects, segments[0], s.renderProject)
+
+	case len(segments) == 2 && segments[1] == "publicShare":
+		s.servePublicShare(w, r, segments[0])
+
+	case segments[1] == "userShares":
+		s.serveUserShares(w, r, segments[0], segments[2:])
+
+	case len(segments) == 2 && segments[1] == "rexReferences":
+		if _, ok := s.projects[segments[0]]; !ok {
+			writeError(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_embedded": map[string]interface{}{"rexReferences": s.projectReferences(segments[0])},
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "Unknown project resource")
+	}
+}
+
+func (s *Server) serveReferences(w http.ResponseWriter, r *request, segments []string) {
+	query := r.URL.Query()
+	switch {
+	case len(segments) == 0:
+		s.serveCollection(w, r, "rexReferences", s.references, s.renderReference, func(data map[string]interface{}) {
+			if data["key"] == nil {
+				data["key"] = "key-" + itoa(s.nextID)
+			}
+			data["project"] = idFromLink(data["project"])
+			data["parentReference"] = idFromLink(data["parentReference"])
+		})
+
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "findByKey":
+		s.serveSearch(w, r, s.references, s.renderReference, "key", query.Get("key"))
+
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "findAllByParentReferenceAndCategory":
+		parent := idFromLink(query.Get("parentReference"))
+		var items []interface{}
+		for _, ref := range sortedResources(s.references) {
+			if ref.data["parentReference"] == parent && ref.data["category"] == query.Get("category") {
+				items = append(items, s.renderReference(ref))
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_embedded": map[string]interface{}{"rexReferences": emptyIfNil(items)},
+		})
+
+	case len(segments) == 1:
+		s.serveResource(w, r, s.references, segments[0], s.renderReference)
+
+	default:
+		writeError(w, http.StatusNotFound, "Unknown rexReference resource")
+	}
+}
+
+func (s *Server) serveUsers(w http.ResponseWriter, r *request, segments []string) {
+	query := r.URL.Query()
+	switch {
+	case len(segments) == 1 && segments[0] == "current":
+		user, ok := s.users[r.caller]
+		if !ok {
+			writeError(w, http.StatusNotFound, "No current user")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"userId": user.id,
+			"_links": links(map[string]string{
+				"self":         s.UsersURL() + "/current",
+				"user":         s.UsersURL() + "/" + user.id,
+				"userLicenses": s.UsersURL() + "/" + user.id + "/userLicenses",
+			}),
+		})
+
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "findUserIdByEmail":
+		s.serveUserID(w, "email", query.Get("email"))
+
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "findUserIdByUsername":
+		s.serveUserID(w, "userName", query.Get("username"))
+
+	case len(segments) == 2 && segments[0] == "search" && segments[1] == "findByUserId":
+		s.serveSearch(w, r, s.users, s.renderUser, "userId", query.Get("userId"))
+
+	case len(segments) == 1:
+		if !s.allowed(w, r, segments[0]) {
+			return
+		}
+		s.serveResource(w, r, s.users, segments[0], s.renderUser)
+
+	case len(segments) == 2 && segments[1] == "userLicenses":
+		if !s.allowed(w, r, segments[0]) {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_embedded": map[string]interface{}{"userLicenses": []interface{}{}},
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "Unknown user resource")
+	}
+}
+
+// serveInvitations invites a user by email. Unknown users are created.
+func (s *Server) serveInvitations(w http.ResponseWriter, r *request, segments []string) {
+	if len(segments) != 0 || r.Method != "POST" {
+		writeError(w, http.StatusMethodNotAllowed, "Invitations can only be created")
+		return
+	}
+	var invitation struct {
+		Email     string `json:"email"`
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	}
+	if err := json.Unmarshal(r.body, &invitation); err != nil || invitation.Email == "" {
+		writeError(w, http.StatusBadRequest, "Invalid invitation")
+		return
+	}
+
+	for _, user := range s.users {
+		if user.data["email"] == invitation.Email {
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"userId": user.id})
+			return
+		}
+	}
+	id := "user-" + s.newID()
+	s.users[id] = newResource(id, map[string]interface{}{
+		"userId":    id,
+		"userName":  invitation.Email,
+		"email":     invitation.Email,
+		"firstName": invitation.FirstName,
+		"lastName":  invitation.LastName,
+	})
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"userId": id})
+}
+
+// serveToken issues service user tokens for the client credentials grant
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	credentials := base64.StdEncoding.EncodeToString([]byte(ClientID + ":" + ClientSecret))
+	if r.Method != "POST" || r.Header.Get("Authorization") != "Basic "+credentials {
+		writeError(w, http.StatusUnauthorized, "Invalid client credentials")
+		return
+	}
+
+	s.mutex.Lock()
+	token := "service-token-" + s.newID()
+	s.serviceUsers[token] = true
+	s.mutex.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   3600,
+		"scope":        "read write",
+		"jti":          token,
+	})
+}
+
+func (s *Server) servePublicShare(w http.ResponseWriter, r *request, projectID string) {
+	if _, ok := s.projects[projectID]; !ok {
+		writeError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	switch r.Method {
+	case "GET":
+	case "PATCH", "PUT":
+		var share struct {
+			Shared bool `json:"shared"`
+		}
+		if err := json.Unmarshal(r.body, &share); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid public share")
+			return
+		}
+		s.publicShares[projectID] = share.Shared
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"shared": s.publicShares[projectID],
+		"_links": links(map[string]string{"self": s.ProjectsURL() + "/" + projectID + "/publicShare"}),
+	})
+}
+
+func (s *Server) serveUserShares(w http.ResponseWriter, r *request, projectID string, segments []string) {
+	if _, ok := s.projects[projectID]; !ok {
+		writeError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	shares := s.userShares[projectID]
+	if shares == nil {
+		shares = make(map[string]string)
+		s.userShares[projectID] = shares
+	}
+	var share struct {
+		User   string `json:"user"`
+		Action string `json:"action"`
+	}
+
+	if len(segments) == 0 {
+		switch r.Method {
+		case "GET":
+			userIDs := make([]string, 0, len(shares))
+			for userID := range shares {
+				userIDs = append(userIDs, userID)
+			}
+			sort.Strings(userIDs)
+			items := make([]interface{}, 0, len(userIDs))
+			for _, userID := range userIDs {
+				items = append(items, map[string]interface{}{"user": userID, "action": shares[userID]})
+			}
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"_embedded": map[string]interface{}{"userShares": items},
+			})
+		case "POST":
+			if err := json.Unmarshal(r.body, &share); err != nil || share.User == "" {
+				writeError(w, http.StatusBadRequest, "Invalid user share")
+				return
+			}
+			if _, ok := shares[share.User]; ok {
+				writeError(w, http.StatusConflict, "User share already exists")
+				return
+			}
+			shares[share.User] = share.Action
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"user": share.User, "action": share.Action})
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	userID := segments[0]
+	action, ok := shares[userID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "User share not found")
+		return
+	}
+	switch r.Method {
+	case "GET":
+	case "PATCH", "PUT":
+		if err := json.Unmarshal(r.body, &share); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid user share")
+			return
+		}
+		action = share.Action
+		shares[userID] = action
+	case "DELETE":
+		delete(shares, userID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user": userID, "action": action})
+}
+
+// serveUserID serves the search for the ID of a user by the given field
+func (s *Server) serveUserID(w http.ResponseWriter, field, value string) {
+	for _, user := range s.users {
+		if value != "" && user.data[field] == value {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"userId": user.id})
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "User not found")
+}
+
+// serveCollection lists the resources page by page or creates a new one
+func (s *Server) serveCollection(w http.ResponseWriter, r *request, name string, resources map[string]*resource, render func(*resource) map[string]interface{}, init func(map[string]interface{})) {
+	switch r.Method {
+	case "GET":
+		query := r.URL.Query()
+		page, _ := strconv.Atoi(query.Get("page"))
+		size, err := strconv.Atoi(query.Get("size"))
+		if err != nil || size <= 0 {
+			size = defaultPageSize
+		}
+
+		all := sortedResources(resources)
+		sortByQuery(all, query["sort"])
+		items := make([]interface{}, 0, size)
+		for i := page * size; i < len(all) && i < (page+1)*size; i++ {
+			items = append(items, render(all[i]))
+		}
+		totalPages := (len(all) + size - 1) / size
+		self := s.URL + r.URL.Path
+		pageLinks := map[string]string{"self": self + "?" + pageQuery(query, page, size)}
+		if page+1 < totalPages {
+			pageLinks["next"] = self + "?" + pageQuery(query, page+1, size)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"_embedded": map[string]interface{}{name: items},
+			"_links":    links(pageLinks),
+			"page": map[string]interface{}{
+				"size":          size,
+				"totalElements": len(all),
+				"totalPages":    totalPages,
+				"number":        page,
+			},
+		})
+
+	case "POST":
+		data := make(map[string]interface{})
+		if err := json.Unmarshal(r.body, &data); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid resource")
+			return
+		}
+		init(data)
+		id := s.newID()
+		resources[id] = newResource(id, data)
+		w.Header().Set("ETag", resources[id].etag())
+		writeJSON(w, http.StatusCreated, render(resources[id]))
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// pageQuery returns the query of the given page, which keeps the sorting of the request
+func pageQuery(query url.Values, page, size int) string {
+	q := url.Values{"page": {itoa(page)}, "size": {itoa(size)}}
+	if sort := query["sort"]; len(sort) > 0 {
+		q["sort"] = sort
+	}
+	return q.Encode()
+}
+
+// sortByQuery sorts the resources by the sort parameters of Spring Data REST, e.g. "name,desc".
+// Values are compared as strings.
+func sortByQuery(resources []*resource, orders []string) {
+	if len(orders) == 0 {
+		return
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		for _, order := range orders {
+			parts := strings.Split(order, ",")
+			a, b := toString(resources[i].data[parts[0]]), toString(resources[j].data[parts[0]])
+			if a == b {
+				continue
+			}
+			if len(parts) > 1 && strings.EqualFold(parts[1], "desc") {
+				return a > b
+			}
+			return a < b
+		}
+		return false
+	})
+}
+
+// serveSearch serves the single resource whose field has the given value
+func (s *Server) serveSearch(w http.ResponseWriter, r *request, resources map[string]*resource, render func(*resource) map[string]interface{}, field, value string) {
+	for _, res := range sortedResources(resources) {
+		if value != "" && res.data[field] == value {
+			w.Header().Set("ETag", res.etag())
+			writeJSON(w, http.StatusOK, render(res))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Resource not found")
+}
+
+// serveResource reads, modifies or deletes a single resource. Modifications are checked
+// against If-Match, reads against If-None-Match.
+func (s *Server) serveResource(w http.ResponseWriter, r *request, resources map[string]*resource, id string, render func(*resource) map[string]interface{}) {
+	res, ok := resources[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Resource not found")
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != res.etag() && r.Method != "GET" && r.Method != "HEAD" {
+		writeError(w, http.StatusPreconditionFailed, "Resource has been modified")
+		return
+	}
+
+	switch r.Method {
+	case "GET", "HEAD":
+		if r.Header.Get("If-None-Match") == res.etag() {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	case "PATCH", "PUT":
+		data := make(map[string]interface{})
+		if err := json.Unmarshal(r.body, &data); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid resource")
+			return
+		}
+		delete(data, "_links")
+		delete(data, "_embedded")
+		for _, association := range []string{"project", "parentReference"} {
+			if v, ok := data[association]; ok {
+				data[association] = idFromLink(v)
+			}
+		}
+		if r.Method == "PUT" {
+			if urn, ok := res.data["urn"]; ok {
+				data["urn"] = urn
+			}
+			res.data = data
+		} else {
+			for k, v := range data {
+				if v == nil {
+					delete(res.data, k)
+				} else {
+					res.data[k] = v
+				}
+			}
+		}
+		res.version++
+	case "DELETE":
+		delete(resources, id)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("ETag", res.etag())
+	writeJSON(w, http.StatusOK, render(res))
+}
+
+// allowed checks that the caller may access the data of the given user. The service user may
+// access the data of all users.
+func (s *Server) allowed(w http.ResponseWriter, r *request, userID string) bool {
+	if r.caller != "" && r.caller != userID {
+		writeError(w, http.StatusForbidden, "Access denied")
+		return false
+	}
+	return true
+}
+
+func (s *Server) renderProject(res *resource) map[string]interface{} {
+	self := s.ProjectsURL() + "/" + res.id
+	return render(res, map[string]string{
+		"self":          self,
+		"project":       self + "{?projection}",
+		"publicShare":   self + "/publicShare",
+		"userShares":    self + "/userShares",
+		"rexReferences": self + "/rexReferences",
+	}, map[string]interface{}{
+		"rexReferences": s.projectReferences(res.id),
+	})
+}
+
+func (s *Server) renderReference(res *resource) map[string]interface{} {
+	self := s.ReferencesURL() + "/" + res.id
+	l := map[string]string{
+		"self":         self,
+		"rexReference": self + "{?projection}",
+		"project":      s.ProjectsURL() + "/" + toString(res.data["project"]),
+	}
+	if parent := toString(res.data["parentReference"]); parent != "" {
+		l["parentReference"] = s.ReferencesURL() + "/" + parent
+	}
+	return render(res, l, nil)
+}
+
+func (s *Server) renderUser(res *resource) map[string]interface{} {
+	return render(res, map[string]string{"self": s.UsersURL() + "/" + res.id}, nil)
+}
+
+// projectReferences returns the rendered references of the project
+func (s *Server) projectReferences(projectID string) []interface{} {
+	items := make([]interface{}, 0)
+	for _, ref := range sortedResources(s.references) {
+		if ref.data["project"] == projectID {
+			items = append(items, s.renderReference(ref))
+		}
+	}
+	return items
+}
+
+// render returns the HAL representation of the resource
+func render(res *resource, l map[string]string, embedded map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(res.data)+2)
+	for k, v := range res.data {
+		if k == "project" || k == "parentReference" {
+			// associations are rendered as links
+			continue
+		}
+		out[k] = v
+	}
+	out["_links"] = links(l)
+	if embedded != nil {
+		out["_embedded"] = embedded
+	}
+	return out
+}
+
+func links(hrefs map[string]string) map[string]interface{} {
+	l := make(map[string]interface{}, len(hrefs))
+	for rel, href := range hrefs {
+		link := map[string]interface{}{"href": href}
+		if strings.Contains(href, "{") {
+			link["templated"] = true
+		}
+		l[rel] = link
+	}
+	return l
+}
+
+// sortedResources returns the resources ordered by their numeric ID
+func sortedResources(resources map[string]*resource) []*resource {
+	all := make([]*resource, 0, len(resources))
+	for _, res := range resources {
+		all = append(all, res)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		a, _ := strconv.Atoi(all[i].id)
+		b, _ := strconv.Atoi(all[j].id)
+		if a != b {
+			return a < b
+		}
+		return all[i].id < all[j].id
+	})
+	return all
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/hal+json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the shape of Spring Boot
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]interface{}{
+		"timestamp": time.Now().UTC().Format("2006-01-02T15:04:05.000+0000"),
+		"status":    code,
+		"error":     http.StatusText(code),
+		"message":   message,
+	})
+}
+
+// projectNumber returns the number of a project URN, e.g. robotic-eyes:project:12 -> 12
+func projectNumber(urn string) string {
+	return urn[strings.LastIndex(urn, ":")+1:]
+}
+
+// idFromLink returns the ID of a resource link, which may also be the ID itself
+func idFromLink(v interface{}) interface{} {
+	link := toString(v)
+	if link == "" {
+		return nil
+	}
+	link = strings.Split(link, "{")[0]
+	return link[strings.LastIndex(link, "/")+1:]
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func emptyIfNil(items []interface{}) []interface{} {
+	if items == nil {
+		return []interface{}{}
+	}
+	return items
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
@@ -0,0 +1,378 @@
+// Package rexostest provides an in-memory fake of REXos for testing composite services without a
+// live gateway. The fake serves HAL resources in the shape of Spring Data REST for projects,
+// rexReferences, users, userShares, publicShare, invitations, statistics and the token endpoint.
+// It can be seeded with fixtures, records all requests for assertions and injects faults.
+package rexostest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roboticeyes/gococo/rexos"
+)
+
+const (
+	// BasePath is the path prefix of all resources
+	BasePath = "/api/v2"
+
+	// TokenPath is the path of the token endpoint
+	TokenPath = "/oauth/token"
+
+	// ClientID is the client ID of the service user
+	ClientID = "rexostest"
+
+	// ClientSecret is the secret of the service user
+	ClientSecret = "secret"
+)
+
+// RecordedRequest is a request which has been received by the server
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// Fault is an error which is returned instead of the response of the matching requests
+type Fault struct {
+	// Method is the method of the matching requests, any method if empty
+	Method string
+
+	// Path is the prefix of the paths of the matching requests, e.g. /api/v2/projects
+	Path string
+
+	// Status is the status code which is returned, no fault is returned if 0 (e.g. for
+	// delaying requests only)
+	Status int
+
+	// Body is the body which is returned together with the status
+	Body string
+
+	// Delay delays the response, requests whose context is canceled in the meantime are aborted
+	Delay time.Duration
+
+	// Times is the number of requests the fault applies to, all requests if 0
+	Times int
+
+	hits int
+}
+
+// Server is a fake REXos whose data is kept in memory. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mutex        sync.Mutex
+	nextID       int
+	projects     map[string]*resource
+	references   map[string]*resource
+	users        map[string]*resource
+	tokens       map[string]string // end-user token -> user ID
+	serviceUsers map[string]bool   // issued service user tokens
+	userShares   map[string]map[string]string
+	publicShares map[string]bool
+	statistics   map[string]rexos.UserStatistics
+	requests     []RecordedRequest
+	faults       []*Fault
+}
+
+// NewServer starts a fake REXos, which must be closed after the test
+func NewServer() *Server {
+	s := &Server{
+		nextID:       1,
+		projects:     make(map[string]*resource),
+		references:   make(map[string]*resource),
+		users:        make(map[string]*resource),
+		tokens:       make(map[string]string),
+		serviceUsers: make(map[string]bool),
+		userShares:   make(map[string]map[string]string),
+		publicShares: make(map[string]bool),
+		statistics:   make(map[string]rexos.UserStatistics),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Config returns the configuration of a client which uses the fake, including the service user
+func (s *Server) Config() rexos.Config {
+	return rexos.Config{
+		AccessTokenURL: s.URL + TokenPath,
+		ClientID:       ClientID,
+		ClientSecret:   ClientSecret,
+	}
+}
+
+// Context returns a context which carries the access token of the given user, as created by
+// rexos.GetRexContext
+func (s *Server) Context(userID string) context.Context {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var token string
+	for t, id := range s.tokens {
+		if id == userID {
+			token = t
+		}
+	}
+	return context.WithValue(context.Background(), rexos.ContextDataKey, rexos.ContextData{
+		AccessToken: "Bearer " + token,
+		UserID:      userID,
+	})
+}
+
+// ProjectsURL returns the URL of the projects resource
+func (s *Server) ProjectsURL() string {
+	return s.URL + BasePath + "/projects"
+}
+
+// ReferencesURL returns the URL of the rexReferences resource
+func (s *Server) ReferencesURL() string {
+	return s.URL + BasePath + "/rexReferences"
+}
+
+// UsersURL returns the URL of the users resource
+func (s *Server) UsersURL() string {
+	return s.URL + BasePath + "/users"
+}
+
+// InvitationsURL returns the URL for inviting new users
+func (s *Server) InvitationsURL() string {
+	return s.URL + BasePath + "/invitations"
+}
+
+// AddUser seeds a user which is authenticated by the given access token
+func (s *Server) AddUser(user rexos.User, token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.addUser(user)
+	s.tokens[token] = user.UserID
+}
+
+// addUser stores the user, the caller must hold the lock
+func (s *Server) addUser(user rexos.User) {
+	s.users[user.UserID] = newResource(user.UserID, map[string]interface{}{
+		"userId":    user.UserID,
+		"userName":  user.UserName,
+		"email":     user.Email,
+		"firstName": user.FirstName,
+		"lastName":  user.LastName,
+	})
+}
+
+// AddProject seeds a project and returns its URN
+func (s *Server) AddProject(project rexos.Project) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := s.newID()
+	urn := "robotic-eyes:project:" + id
+	s.projects[id] = newResource(id, map[string]interface{}{
+		"name":  project.Name,
+		"owner": project.Owner,
+		"urn":   urn,
+	})
+	return urn
+}
+
+// AddReference seeds a reference of the project with the given URN and returns its key
+func (s *Server) AddReference(projectUrn string, reference rexos.Reference) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := s.newID()
+	if reference.Key == "" {
+		reference.Key = "key-" + id
+	}
+	s.references[id] = newResource(id, map[string]interface{}{
+		"key":             reference.Key,
+		"name":            reference.Name,
+		"type":            reference.Type,
+		"category":        reference.Category,
+		"rootReference":   reference.RootReference,
+		"parentReference": idFromLink(reference.ParentReference),
+		"project":         projectNumber(projectUrn),
+	})
+	return reference.Key
+}
+
+// SetUserShare seeds the share of the project with the given URN with a user, action is READ
+// or WRITE
+func (s *Server) SetUserShare(projectUrn, userID, action string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	id := projectNumber(projectUrn)
+	if s.userShares[id] == nil {
+		s.userShares[id] = make(map[string]string)
+	}
+	s.userShares[id][userID] = action
+}
+
+// SetPublicShare seeds the public sharing of the project with the given URN
+func (s *Server) SetPublicShare(projectUrn string, shared bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.publicShares[projectNumber(projectUrn)] = shared
+}
+
+// SetStatistics seeds the statistics of the user
+func (s *Server) SetStatistics(userID string, statistics rexos.UserStatistics) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.statistics[userID] = statistics
+}
+
+// Project returns the current state of the project with the given URN, false if it does not
+// exist
+func (s *Server) Project(projectUrn string) (rexos.Project, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r, ok := s.projects[projectNumber(projectUrn)]
+	if !ok {
+		return rexos.Project{}, false
+	}
+	var project rexos.Project
+	r.decode(&project)
+	return project, true
+}
+
+// UserShares returns the actions of the users the project with the given URN is shared with
+func (s *Server) UserShares(projectUrn string) map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	shares := make(map[string]string)
+	for userID, action := range s.userShares[projectNumber(projectUrn)] {
+		shares[userID] = action
+	}
+	return shares
+}
+
+// InjectFault makes the server return the fault for the matching requests. Faults are checked
+// in the order they have been injected.
+func (s *Server) InjectFault(fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = nil
+}
+
+// Requests returns all requests which have been received so far
+func (s *Server) Requests() []RecordedRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// RequestsTo returns the received requests with the given method and path
+func (s *Server) RequestsTo(method, path string) []RecordedRequest {
+	var matching []RecordedRequest
+	for _, r := range s.Requests() {
+		if r.Method == method && r.Path == path {
+			matching = append(matching, r)
+		}
+	}
+	return matching
+}
+
+// AssertRequested fails the test if the server did not receive the given number of requests
+// with the given method and path
+func (s *Server) AssertRequested(t testing.TB, method, path string, count int) {
+	t.Helper()
+	if n := len(s.RequestsTo(method, path)); n != count {
+		t.Fatalf("Expected %d %s requests to %s, got %d", count, method, path, n)
+	}
+}
+
+// serveHTTP records the request, applies the faults and dispatches the request
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	fault := s.fault(r)
+	s.mutex.Unlock()
+
+	if fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.Status != 0 {
+			w.WriteHeader(fault.Status)
+			w.Write([]byte(fault.Body))
+			return
+		}
+	}
+
+	if r.URL.Path == TokenPath {
+		s.serveToken(w, r)
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, BasePath+"/") {
+		writeError(w, http.StatusNotFound, "Unknown path "+r.URL.Path)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	caller, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Invalid access token")
+		return
+	}
+	s.route(w, &request{Request: r, body: body, caller: caller})
+}
+
+// fault returns the first fault matching the request, the caller must hold the lock
+func (s *Server) fault(r *http.Request) *Fault {
+	for _, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method || !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+		f.hits++
+		return f
+	}
+	return nil
+}
+
+// authenticate returns the user ID of the caller, which is empty for the service user. The
+// caller must hold the lock.
+func (s *Server) authenticate(r *http.Request) (string, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.serviceUsers[token] {
+		return "", true
+	}
+	userID, ok := s.tokens[token]
+	return userID, ok
+}
+
+// newID returns a new numeric ID, the caller must hold the lock
+func (s *Server) newID() string {
+	id := s.nextID
+	s.nextID++
+	return itoa(id)
+}
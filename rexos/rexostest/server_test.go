@@ -0,0 +1,198 @@
+package rexostest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roboticeyes/gococo/rexos"
+)
+
+func newTestServer() *Server {
+	s := NewServer()
+	s.AddUser(rexos.User{UserID: "alice", UserName: "alice", Email: "alice@rexos.test"}, "alice-token")
+	s.AddUser(rexos.User{UserID: "bob", UserName: "bob", Email: "bob@rexos.test", FirstName: "Bob"}, "bob-token")
+	return s
+}
+
+func TestTransferProject(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	urn := s.AddProject(rexos.Project{Name: "Machine 1", Owner: "alice"})
+
+	service := rexos.NewService(rexos.Config{NotApplyServiceUser: true})
+	project, ret := service.TransferProject(s.Context("alice"), s.ProjectsURL(), s.UsersURL(), urn, "bob@rexos.test")
+	if ret != nil {
+		t.Fatal("Transfer failed", ret)
+	}
+	if project.Owner != "bob" {
+		t.Fatal("Wrong owner in result", project.Owner)
+	}
+	if stored, _ := s.Project(urn); stored.Owner != "bob" {
+		t.Fatal("Owner has not been updated", stored.Owner)
+	}
+	s.AssertRequested(t, "PATCH", BasePath+"/projects/"+projectNumber(urn), 1)
+
+	// the If-Match header of the update makes the fake check the version
+	if r := s.RequestsTo("PATCH", BasePath+"/projects/"+projectNumber(urn)); r[0].Header.Get("If-Match") == "" {
+		t.Fatal("Update is not conditional")
+	}
+}
+
+func TestCreateProjectInvitation(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	urn := s.AddProject(rexos.Project{Name: "Machine 1", Owner: "alice"})
+	key := s.AddReference(urn, rexos.Reference{Type: rexos.ReferenceTypePortal, Name: "Portal"})
+
+	service := rexos.NewService(rexos.Config{NotApplyServiceUser: true})
+	invitation := rexos.ProjectInvitation{User: rexos.UserData{Email: "carol@rexos.test"}, Read: true}
+	_, ret := service.CreateProjectInvitation(s.Context("alice"), urn, invitation, s.ProjectsURL(), s.UsersURL(), s.InvitationsURL(), "https://rex.codes/v1")
+	if ret != nil {
+		t.Fatal("Invitation failed", ret)
+	}
+
+	requests := s.RequestsTo("POST", BasePath+"/invitations")
+	if len(requests) != 1 || !strings.Contains(string(requests[0].Body), "https://rex.codes/v1/"+key) {
+		t.Fatal("Wrong invitation", requests)
+	}
+	shares := s.UserShares(urn)
+	if len(shares) != 1 {
+		t.Fatal("Project has not been shared", shares)
+	}
+	for _, action := range shares {
+		if action != "READ" {
+			t.Fatal("Wrong action", action)
+		}
+	}
+}
+
+func TestGetShareWithServiceUser(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	urn := s.AddProject(rexos.Project{Name: "Machine 1", Owner: "alice"})
+	s.SetPublicShare(urn, true)
+	s.SetUserShare(urn, "bob", "READ")
+
+	service := rexos.NewService(s.Config())
+	ctx := s.Context("alice")
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		// the service user token is requested in the background
+		_, ret := service.GetHalResourceWithServiceUser(ctx, "Users", s.UsersURL()+"/search/findByUserId?userId=bob")
+		if ret == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("No service user token", ret)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	share, ret := service.GetShare(ctx, s.ProjectsURL(), s.UsersURL(), urn)
+	if ret != nil {
+		t.Fatal("Get share failed", ret)
+	}
+	if share.PublicShare == nil || !*share.PublicShare {
+		t.Fatal("Wrong public share", share.PublicShare)
+	}
+	if len(share.UserShares) != 1 || share.UserShares[0].User.FirstName != "Bob" || !share.UserShares[0].Read {
+		t.Fatal("Wrong user shares", share.UserShares)
+	}
+}
+
+func TestGetUserStatistics(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	s.SetStatistics("alice", rexos.UserStatistics{NumberOfProjects: 3})
+
+	service := rexos.NewService(rexos.Config{NotApplyServiceUser: true})
+	statistics, ret := service.GetUserStatistics(s.Context("alice"), s.ProjectsURL())
+	if ret != nil || statistics.NumberOfProjects != 3 {
+		t.Fatal("Wrong statistics", statistics, ret)
+	}
+
+	if _, ret := service.GetUserStatistics(s.Context("bob"), s.ProjectsURL()); ret != nil {
+		t.Fatal("Statistics of the caller not accessible", ret)
+	}
+	ctx := context.WithValue(context.Background(), rexos.ContextDataKey, rexos.ContextData{AccessToken: "Bearer bob-token", UserID: "alice"})
+	if _, ret := service.GetUserStatistics(ctx, s.ProjectsURL()); ret == nil || ret.Code != http.StatusForbidden {
+		t.Fatal("Statistics of other users accessible", ret)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	urn := s.AddProject(rexos.Project{Name: "Machine 1", Owner: "alice"})
+	s.InjectFault(Fault{Method: "GET", Path: BasePath + "/projects", Status: http.StatusInternalServerError, Times: 1})
+
+	service := rexos.NewService(rexos.Config{NotApplyServiceUser: true})
+	query := rexos.QueryFindByUrn(s.ProjectsURL(), urn)
+	if _, ret := service.GetHalResource(s.Context("alice"), "Project", query); ret == nil || ret.Code != http.StatusInternalServerError {
+		t.Fatal("Fault has not been injected", ret)
+	}
+	if _, ret := service.GetHalResource(s.Context("alice"), "Project", query); ret != nil {
+		t.Fatal("Fault has been injected too often", ret)
+	}
+
+	s.InjectFault(Fault{Path: BasePath, Delay: time.Second})
+	ctx, cancel := context.WithTimeout(s.Context("alice"), time.Millisecond*50)
+	defer cancel()
+	if _, ret := service.GetHalResource(ctx, "Project", query); !ret.IsDeadlineExceeded() {
+		t.Fatal("Request has not been delayed", ret)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	for _, name := range []string{"c", "a", "e", "b", "d"} {
+		s.AddProject(rexos.Project{Name: name, Owner: "alice"})
+	}
+	s.AddProject(rexos.Project{Name: "a", Owner: "bob"})
+
+	service := rexos.NewService(rexos.Config{NotApplyServiceUser: true})
+	request := rexos.PageRequest{Size: 2, Sort: []rexos.SortOrder{rexos.Asc("name"), rexos.Desc("owner")}}
+	pager := service.Paginate(s.Context("alice"), "Projects", s.ProjectsURL(), "projects", request)
+	if pager.TotalElements() != 6 || pager.TotalPages() != 3 {
+		t.Fatal("Wrong page metadata", pager.Page())
+	}
+
+	var projects []rexos.Project
+	if ret := pager.All(&projects); ret != nil {
+		t.Fatal("Listing failed", ret)
+	}
+	var names []string
+	for _, p := range projects {
+		names = append(names, p.Name+"/"+p.Owner)
+	}
+	if strings.Join(names, " ") != "a/bob a/alice b/alice c/alice d/alice e/alice" {
+		t.Fatal("Wrong projects", names)
+	}
+	// the first page is not fetched again for the items
+	if n := len(s.RequestsTo("GET", BasePath+"/projects")); n != 3 {
+		t.Fatal("Wrong number of page requests", n)
+	}
+	if q := s.RequestsTo("GET", BasePath+"/projects")[2].Query; !strings.Contains(q, "sort=name%2Casc&sort=owner%2Cdesc") {
+		t.Fatal("Sorting not kept in next link", q)
+	}
+}
+
+func TestShareWithPlusAddress(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+	s.AddUser(rexos.User{UserID: "carol", UserName: "carol", Email: "carol+rex&co@rexos.test"}, "carol-token")
+	urn := s.AddProject(rexos.Project{Name: "Machine 1", Owner: "alice"})
+
+	service := rexos.NewService(rexos.Config{NotApplyServiceUser: true})
+	userShare := rexos.UserShare{User: rexos.User{Email: "carol+rex&co@rexos.test"}, Write: true}
+	if _, ret := service.CreateOrUpdateUserShare(s.Context("alice"), s.ProjectsURL(), s.UsersURL(), urn, userShare); ret != nil {
+		t.Fatal("Share failed", ret)
+	}
+	if shares := s.UserShares(urn); shares["carol"] != "WRITE" {
+		t.Fatal("Project has not been shared", shares)
+	}
+}
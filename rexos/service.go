@@ -4,15 +4,15 @@ package rexos
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/roboticeyes/gococo/event"
+	"github.com/roboticeyes/gococo/hal"
 	"github.com/roboticeyes/gococo/status"
 	"github.com/tidwall/gjson"
 )
@@ -24,10 +24,6 @@ type Service struct {
 	client *Client // this is the client which is used to perform the REXos calls
 }
 
-type postFunction func(ctx context.Context, query string, payload io.Reader, contentType string) ([]byte, int, error)
-type getFunction func(ctx context.Context, query string, authenticate bool) (string, []byte, int, error)
-type patchFunction func(ctx context.Context, query string, payload io.Reader, contentType string) ([]byte, int, error)
-
 // NewService returns a new rexos service which is implementing the RexOSAccessor interface
 func NewService(config Config) *Service {
 
@@ -36,16 +32,73 @@ func NewService(config Config) *Service {
 	}
 }
 
+// NewServiceWithOptions returns a new rexos service whose client is configured by the given options
+func NewServiceWithOptions(config Config, opts ...ClientOption) (*Service, error) {
+	client, err := NewClientWithOptions(config, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewServiceWithClient(client), nil
+}
+
+// NewServiceWithClient returns a new rexos service which uses the given client. This allows
+// sharing one client among several services.
+func NewServiceWithClient(client *Client) *Service {
+
+	return &Service{
+		client: client,
+	}
+}
+
+// newRequestStatus creates the status for a failed REXos request. Requests which got aborted by
+// their context, rejected by the circuit breaker or the rate limiter or transferred corrupted
+// content are reported as such, all others with the given code.
+func newRequestStatus(body []byte, code int, err error, message string) *status.Status {
+	if s := status.NewContextStatus(err, message); s != nil {
+		return s
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return status.NewCircuitOpenStatus(message)
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return status.NewRateLimitedStatus(message)
+	}
+	var integrityErr *IntegrityError
+	if errors.As(err, &integrityErr) {
+		return status.NewIntegrityStatus(message + ": " + integrityErr.Error())
+	}
+	return status.NewStatus(body, code, message)
+}
+
 // StripTemplateParameter removes the trailing template parameters of an HATEOAS URL
 // For example: "https://rex.robotic-eyes.com/rex-gateway/api/v2/rexReferences/1000/project{?projection}"
+// Use hal.Link.Expand to fill in the template variables instead.
 func StripTemplateParameter(templateURL string) string {
 	return strings.Split(templateURL, "{")[0]
 }
 
+// getLinkFromHal returns the link with the given relation of a HAL resource with all template
+// variables removed, empty if the resource has no such link
+func getLinkFromHal(json []byte, rel string) string {
+	r, err := hal.Parse(json)
+	if err != nil {
+		return ""
+	}
+	link, ok := r.Link(rel)
+	if !ok {
+		return ""
+	}
+	u, err := hal.Expand(link.Href, nil)
+	if err != nil {
+		return StripTemplateParameter(link.Href)
+	}
+	return u
+}
+
 // GetSelfLinkFromHal returns the stripped self link of a HAL resource. The input is the JSON
 // response as string
 func GetSelfLinkFromHal(json []byte) string {
-	return StripTemplateParameter(gjson.Get(string(json), "_links.self.href").String())
+	return getLinkFromHal(json, hal.RelSelf)
 }
 
 // GetUrnFromHal returns the urn of a resource which was returned as a HAL response
@@ -56,195 +109,123 @@ func GetUrnFromHal(json []byte) string {
 // GetPublicShareLinkFromHal returns the stripped public share link of a HAL resource. The input is the JSON
 // response as string
 func GetPublicShareLinkFromHal(json []byte) string {
-	return StripTemplateParameter(gjson.Get(string(json), "_links.publicShare.href").String())
+	return getLinkFromHal(json, "publicShare")
 }
 
 // GetProjectLinkFromHal returns the stripped project link of a HAL resource. The input is the JSON
 // response as string
 func GetProjectLinkFromHal(json []byte) string {
-	return StripTemplateParameter(gjson.Get(string(json), "_links.project.href").String())
+	return getLinkFromHal(json, "project")
 }
 
 // GetHalResourceWithServiceUser returns the requested resource which got fetched with the service user - x-forwarded header fields added
 func (s *Service) GetHalResourceWithServiceUser(ctx context.Context, resourceName, url string) ([]byte, *status.Status) {
-	return s.getHalResource(ctx, resourceName, url, s.client.GetWithServiceUser)
+	return s.GetResource(ctx, resourceName, url, AsServiceUser())
 }
 
 // GetHalResource returns the requested resource - x-forwarded header fields added
 func (s *Service) GetHalResource(ctx context.Context, resourceName, url string) ([]byte, *status.Status) {
-	return s.getHalResource(ctx, resourceName, url, s.client.Get)
+	return s.GetResource(ctx, resourceName, url)
 }
 
 // GetHalResourceWithServiceUserNoXF returns the requested resource which got fetched with the service user - x-forwarded header fields not added
 func (s *Service) GetHalResourceWithServiceUserNoXF(ctx context.Context, resourceName, url string) ([]byte, *status.Status) {
-	return s.getHalResource(ctx, resourceName, url, s.client.GetWithServiceUserNoXF)
+	return s.GetResource(ctx, resourceName, url, AsServiceUser(), WithXForwarded(false))
 }
 
 // GetHalResourceNoXF returns the requested resource - x-forwarded header fields not added
 func (s *Service) GetHalResourceNoXF(ctx context.Context, resourceName, url string) ([]byte, *status.Status) {
-	return s.getHalResource(ctx, resourceName, url, s.client.GetNoXF)
-}
-
-// getHalResource performs the GET request for getting a rexos resource
-// Returns the body in case of success. If an error occurred, then the according
-// status is returned. The resourceName is used for the error message
-func (s *Service) getHalResource(ctx context.Context, resourceName, url string, gf getFunction) ([]byte, *status.Status) {
-
-	var body []byte
-	var code int
-	var err error
-
-	_, body, code, err = gf(ctx, url, true)
-
-	if err != nil {
-		log.WithFields(event.Fields{
-			"resourceName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Can not get HAL resource: " + err.Error())
-		return []byte{}, status.NewStatus(body, code, "Can not get resource "+resourceName)
-	}
-
-	// A GET request should return a value in range of [200,300[
-	if code < http.StatusOK || code >= http.StatusMultipleChoices {
-		log.WithFields(event.Fields{
-			"resourceName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Can not get HAL resource")
-		return []byte{}, status.NewStatus(body, code, "Can not get resource "+resourceName)
-	}
-	return body, nil
+	return s.GetResource(ctx, resourceName, url, WithXForwarded(false))
 }
 
 // CreateHalResourceWithServiceUser uses the service user credential to create a new resource - no x-forwarded header fields added
 func (s *Service) CreateHalResourceWithServiceUser(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.createHalResource(ctx, resourceName, url, r, s.client.PostWithServiceUser)
+	return s.CreateResource(ctx, resourceName, url, r, AsServiceUser())
 }
 
 // CreateHalResource creates a new resource with the caller's credentialsa - no x-forwarded header fields added
 func (s *Service) CreateHalResource(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.createHalResource(ctx, resourceName, url, r, s.client.Post)
+	return s.CreateResource(ctx, resourceName, url, r)
 }
 
 // CreateHalResourceWithServiceUserWithXF uses the service user credential to create a new resource - x-forwarded header fields added
 func (s *Service) CreateHalResourceWithServiceUserWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.createHalResource(ctx, resourceName, url, r, s.client.PostWithServiceUserWithXF)
+	return s.CreateResource(ctx, resourceName, url, r, AsServiceUser(), WithXForwarded(true))
 }
 
 // CreateHalResourceWithXF creates a new resource with the caller's credentials - x-forwarded header fields added
 func (s *Service) CreateHalResourceWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.createHalResource(ctx, resourceName, url, r, s.client.PostWithXF)
-}
-
-// CreateHalResource creates a new resource which needs to be able to write itself to the proper
-// JSON string. In case of success, the body is returned. The resourceName is just for error handling.
-// The url is required to identify the endpoint
-func (s *Service) createHalResource(ctx context.Context, resourceName, url string, r interface{}, pf postFunction) ([]byte, *status.Status) {
-
-	var body []byte
-	var code int
-	var err error
-
-	b := new(bytes.Buffer)
-	json.NewEncoder(b).Encode(r)
-
-	body, code, err = pf(ctx, url, b, "application/json")
-	if err != nil {
-		log.WithFields(event.Fields{
-			"resourceName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Can not create HAL resource: " + err.Error())
-		return []byte{}, status.NewStatus(body, code, "Can not create resource "+resourceName)
-	}
-
-	// A POST request should return a value in range of [200,300[
-	if code < http.StatusOK || code >= http.StatusMultipleChoices {
-		log.WithFields(event.Fields{
-			"resourceName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Can not create HAL resource")
-		return []byte{}, status.NewStatus(body, code, "Can not create resource "+resourceName)
-	}
-	return body, nil
+	return s.CreateResource(ctx, resourceName, url, r, WithXForwarded(true))
 }
 
 // PatchHalResourceWithServiceUser patches the resource with the service user
 func (s *Service) PatchHalResourceWithServiceUser(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.patchHalResource(ctx, resourceName, url, r, s.client.PatchWithServiceUser)
+	return s.PatchResource(ctx, resourceName, url, r, AsServiceUser())
 }
 
 // PatchHalResource patches the resource with the caller's credentials
 func (s *Service) PatchHalResource(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.patchHalResource(ctx, resourceName, url, r, s.client.Patch)
+	return s.PatchResource(ctx, resourceName, url, r)
 }
 
 // PatchHalResourceWithServiceUserWithXF patches the resource with the service user
 func (s *Service) PatchHalResourceWithServiceUserWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.patchHalResource(ctx, resourceName, url, r, s.client.PatchWithServiceUserWithXF)
+	return s.PatchResource(ctx, resourceName, url, r, AsServiceUser(), WithXForwarded(true))
 }
 
 // PatchHalResourceWithXF patches the resource with the caller's credentials
 func (s *Service) PatchHalResourceWithXF(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
-	return s.patchHalResource(ctx, resourceName, url, r, s.client.PatchWithXF)
+	return s.PatchResource(ctx, resourceName, url, r, WithXForwarded(true))
 }
 
-// PatchHalResource sends a partial update to the requested resource
-func (s *Service) patchHalResource(ctx context.Context, resourceName, url string, r interface{}, pf patchFunction) ([]byte, *status.Status) {
+// DeleteHalResource deletes the resource with the caller's credentials
+func (s *Service) DeleteHalResource(ctx context.Context, resourceName, url string) *status.Status {
+	return s.DeleteResource(ctx, resourceName, url)
+}
 
-	var body []byte
-	var code int
-	var err error
+// ReplaceHalResourceWithServiceUser replaces the resource with the service user
+func (s *Service) ReplaceHalResourceWithServiceUser(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
+	return s.ReplaceResource(ctx, resourceName, url, r, AsServiceUser())
+}
 
-	b := new(bytes.Buffer)
-	json.NewEncoder(b).Encode(r)
+// ReplaceHalResource replaces the resource (full update) with the caller's credentials
+func (s *Service) ReplaceHalResource(ctx context.Context, resourceName, url string, r interface{}) ([]byte, *status.Status) {
+	return s.ReplaceResource(ctx, resourceName, url, r)
+}
 
-	body, code, err = pf(ctx, url, b, "application/json")
-	if err != nil {
-		log.WithFields(event.Fields{
-			"resourceName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Can not patch HAL resource: " + err.Error())
-		return []byte{}, status.NewStatus(body, code, "Can not modify resource "+resourceName)
-	}
+// LinkHalResources replaces the associated resources of an association resource (e.g.
+// .../rexReferences/1000/project) by the given resource links with the caller's credentials
+func (s *Service) LinkHalResources(ctx context.Context, resourceName, associationURL string, links ...string) *status.Status {
+	payload := strings.NewReader(strings.Join(links, "\n"))
+	_, ret := s.SendResource(ctx, resourceName, Request{Method: "PUT", URL: associationURL, Payload: payload, ContentType: "text/uri-list"})
+	return ret
+}
 
-	// A PATCH request should return a value in range of [200,300[
-	if code < http.StatusOK || code >= http.StatusMultipleChoices {
-		log.WithFields(event.Fields{
-			"resourceName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Can not patch HAL resource")
-		return []byte{}, status.NewStatus(body, code, "Can not modify resource "+resourceName)
-	}
-	return body, nil
+// AddHalResourceLinks adds the given resource links to a collection association resource
+// with the caller's credentials
+func (s *Service) AddHalResourceLinks(ctx context.Context, resourceName, associationURL string, links ...string) *status.Status {
+	payload := strings.NewReader(strings.Join(links, "\n"))
+	_, ret := s.SendResource(ctx, resourceName, Request{Method: "POST", URL: associationURL, Payload: payload, ContentType: "text/uri-list"})
+	return ret
 }
 
-// DeleteHalResource patches the resource with the caller's credentials
-func (s *Service) DeleteHalResource(ctx context.Context, resourceName, url string) *status.Status {
-	_, code, err := s.client.Delete(ctx, url)
-	if err != nil {
-		log.WithFields(event.Fields{
-			"resoucreName": resourceName,
-			"code":         code,
-			"url":          url,
-		}).Error("Failed to delete HAL resource" + err.Error())
-		return status.NewStatus(nil, code, "Can not delete resource "+resourceName)
+// HalResourceExists checks with a HEAD request if the resource exists. The request is sent
+// with the caller's credentials unless changed by the options.
+func (s *Service) HalResourceExists(ctx context.Context, resourceName, url string, opts ...CallOption) (bool, *status.Status) {
+	o := newCallOptions(false, opts)
+	resp, err := s.send(ctx, resourceName, Request{Method: http.MethodHead, URL: url}, o)
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
 	}
-
-	// A DELETE request should return a value in range of [200,300[
-	if code < http.StatusOK || code >= http.StatusMultipleChoices {
-		log.WithFields(event.Fields{
+	if err != nil {
+		log.WithContext(ctx).WithFields(event.Fields{
 			"resourceName": resourceName,
-			"code":         code,
+			"code":         resp.StatusCode,
 			"url":          url,
-		}).Error("Can not delete HAL resource")
-		return status.NewStatus(nil, code, "Can not delete resource "+resourceName)
+		}).Error("Can not check HAL resource: " + err.Error())
+		return false, newRequestStatus(nil, resp.StatusCode, err, "Can not check resource "+resourceName)
 	}
-	return nil
+	return true, nil
 }
 
 // DownloadFileContent uploads the actual binary file for a project file
@@ -255,15 +236,15 @@ func (s *Service) DownloadFileContent(ctx context.Context, downloadURL string, a
 		fileName = "file.rex"
 	}
 	if err != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"downloadUrl": downloadURL,
 			"fileName":    fileName,
 		}).Error("Can not download file content: " + err.Error())
-		return []byte{}, status.NewStatus(nil, code, "Can not access file "+fileName)
+		return []byte{}, newRequestStatus(nil, code, err, "Can not access file "+fileName)
 	}
 
 	if code != http.StatusOK {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"downloadUrl": downloadURL,
 			"fileName":    fileName,
 		}).Error("Can not download file content")
@@ -273,113 +254,106 @@ func (s *Service) DownloadFileContent(ctx context.Context, downloadURL string, a
 	return blob, nil
 }
 
-// UploadFileContent uploads the actual binary file for a project file
-func (s *Service) UploadFileContent(ctx context.Context, uploadURL string, downloadURL string, authenticate bool) *status.Status {
-
-	// download file content
-	fileName, blob, code, err := s.client.Get(ctx, downloadURL, authenticate)
-	if fileName == "" {
-		fileName = "file.rex"
-	}
+// DownloadFileStream opens the binary file of a project file for streaming. In contrast to
+// DownloadFileContent the content is not held in memory, it is read from the returned download
+// which must be closed by the caller. Please note that the deadline of the context must allow
+// for the whole transfer.
+func (s *Service) DownloadFileStream(ctx context.Context, downloadURL string, authenticate bool) (*FileDownload, *status.Status) {
+	download, code, err := s.client.GetStream(ctx, downloadURL, authenticate)
 	if err != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"downloadUrl": downloadURL,
-			"uploadUrl":   uploadURL,
-			"fileName":    fileName,
 		}).Error("Can not download file content: " + err.Error())
-		return status.NewStatus(nil, code, "Can not access file "+fileName)
+		return nil, newRequestStatus(nil, code, err, "Can not access file "+downloadURL)
 	}
 
 	if code != http.StatusOK {
-		log.WithFields(event.Fields{
+		download.Close()
+		log.WithContext(ctx).WithFields(event.Fields{
 			"downloadUrl": downloadURL,
-			"uploadUrl":   uploadURL,
-			"fileName":    fileName,
 		}).Error("Can not download file content")
-		return status.NewStatus(nil, code, "Can not access file "+fileName)
+		return nil, status.NewStatus(nil, code, "Can not access file "+downloadURL)
 	}
 
-	// get filename from header information
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, _ := writer.CreateFormFile("file", fileName)
+	if download.FileName == "" {
+		download.FileName = "file.rex"
+	}
+	return download, nil
+}
 
-	io.Copy(part, bytes.NewReader(blob))
-	writer.Close()
+// UploadFileContent uploads the actual binary file for a project file. The content is copied
+// from the download to the upload without holding it in memory.
+func (s *Service) UploadFileContent(ctx context.Context, uploadURL string, downloadURL string, authenticate bool) *status.Status {
 
-	responseBody, code, err := s.client.Post(ctx, uploadURL, body, writer.FormDataContentType())
+	// download file content
+	download, code, err := s.client.GetStream(ctx, downloadURL, authenticate)
 	if err != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"downloadUrl": downloadURL,
 			"uploadUrl":   uploadURL,
-			"fileName":    fileName,
-		}).Error("Can not upload file content: " + err.Error())
-		return status.NewStatus(responseBody, code, "Can not upload file "+fileName)
+		}).Error("Can not download file content: " + err.Error())
+		return newRequestStatus(nil, code, err, "Can not access file "+downloadURL)
+	}
+	defer download.Close()
+
+	fileName := download.FileName
+	if fileName == "" {
+		fileName = "file.rex"
 	}
 	if code != http.StatusOK {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"downloadUrl": downloadURL,
 			"uploadUrl":   uploadURL,
 			"fileName":    fileName,
-		}).Error("Can not upload file content: " + err.Error())
-		return status.NewStatus(responseBody, code, "Can not upload file "+fileName)
+		}).Error("Can not download file content")
+		return status.NewStatus(nil, code, "Can not access file "+fileName)
 	}
-	return nil
+
+	return s.UploadMultipart(ctx, uploadURL, MultipartUpload{
+		FileName:      fileName,
+		Content:       download,
+		ContentLength: download.ContentLength,
+	})
 }
 
 // UploadMultipartFile uploads the content of a multipart file
 func (s *Service) UploadMultipartFile(ctx context.Context, fileName string, uploadURL string, data io.Reader) *status.Status {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, _ := writer.CreateFormFile("file", fileName)
-
-	io.Copy(part, data)
-	writer.Close()
-
-	responseBody, code, err := s.client.Post(ctx, uploadURL, body, writer.FormDataContentType())
-
-	// responseBody, code, err := s.client.Post(ctx, uploadURL, data, contentType)
-	if err != nil {
-		log.WithFields(event.Fields{
-			"uploadUrl": uploadURL,
-			"fileName":  fileName,
-		}).Error("Can not upload file content: " + err.Error())
-		return status.NewStatus(responseBody, code, "Can not upload file "+fileName)
-	}
-	if code != http.StatusOK {
-		log.WithFields(event.Fields{
-			"uploadUrl": uploadURL,
-			"fileName":  fileName,
-		}).Error("Can not upload file content: " + err.Error())
-		return status.NewStatus(responseBody, code, "Can not upload file "+fileName)
-	}
-	return nil
+	return s.UploadMultipart(ctx, uploadURL, MultipartUpload{
+		FileName: fileName,
+		Content:  data,
+	})
 }
 
 // UploadFile uploads the byte array as file
 func (s *Service) UploadFile(ctx context.Context, fileName string, uploadURL string, data []byte) *status.Status {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, _ := writer.CreateFormFile("file", fileName)
+	return s.UploadMultipart(ctx, uploadURL, MultipartUpload{
+		FileName:      fileName,
+		Content:       bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+	})
+}
 
-	io.Copy(part, bytes.NewReader(data))
-	writer.Close()
+// UploadMultipart streams the file and the additional form fields of the upload as multipart
+// form to the given URL
+func (s *Service) UploadMultipart(ctx context.Context, uploadURL string, upload MultipartUpload) *status.Status {
 
-	responseBody, code, err := s.client.Post(ctx, uploadURL, body, writer.FormDataContentType())
+	payload, body, contentType := upload.payload()
+	defer body.Close()
+
+	responseBody, code, err := s.client.Post(ctx, uploadURL, payload, contentType)
 	if err != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"uploadUrl": uploadURL,
-			"fileName":  fileName,
+			"fileName":  upload.FileName,
 		}).Error("Can not upload file content: " + err.Error())
-		return status.NewStatus(responseBody, code, "Can not upload file "+fileName)
+		return newRequestStatus(responseBody, code, err, "Can not upload file "+upload.FileName)
 	}
 	if code != http.StatusOK {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"uploadUrl": uploadURL,
-			"fileName":  fileName,
-		}).Error("Can not upload file content: " + err.Error())
-		return status.NewStatus(responseBody, code, "Can not upload file "+fileName)
+			"fileName":  upload.FileName,
+		}).Error("Can not upload file content")
+		return status.NewStatus(responseBody, code, "Can not upload file "+upload.FileName)
 	}
 	return nil
 }
@@ -388,13 +362,11 @@ func (s *Service) UploadFile(ctx context.Context, fileName string, uploadURL str
 // e.g. https://api-dev-01.rexos.cloud/rex-gateway/api/v2/projectFiles/1747/file?contentHash=2dd1aee5e71621ea56042c92886be464
 func GetHashFromDownloadLink(link string) string {
 
-	res := strings.Split(link, "=")
-
-	if len(res) < 2 {
+	u, err := url.Parse(link)
+	if err != nil {
 		return ""
 	}
-
-	return res[1]
+	return u.Query().Get("contentHash")
 }
 
 // GetGUIDFromRexTagURL extracts the GUID of the portal reference based on the actual
@@ -438,13 +410,13 @@ func (s *Service) GetFileWithServiceUser(ctx context.Context, c *gin.Context, ur
 	code, err := s.client.GetFileWithServiceUser(ctx, c, url, true)
 
 	if err != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"url": url,
 		}).Debug("Can not get file: " + err.Error())
-		return status.NewStatus([]byte{}, code, "Can not get file from "+url)
+		return newRequestStatus([]byte{}, code, err, "Can not get file from "+url)
 	}
 	if code != http.StatusOK {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"url": url,
 		}).Debug("Can not get file ")
 		return status.NewStatus([]byte{}, code, "Can not get file from "+url)
@@ -60,11 +60,15 @@ func (s *Service) GetShare(ctx context.Context, projectResourceURL, userResource
 		return share, ret
 	}
 
+	// The share resources of all functions below are addressed by the project number instead of
+	// following the links of the project, which would take an additional request for fetching
+	// the project first.
+
 	// get public sharing information
 	query := projectResourceURL + "/" + projectNumber + "/publicShare"
 	publicShareResult, ret := s.GetHalResource(ctx, "Projects", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status":     ret,
 			"projectUrn": projectUrn,
 			"query":      query,
@@ -80,7 +84,7 @@ func (s *Service) GetShare(ctx context.Context, projectResourceURL, userResource
 	query = projectResourceURL + "/" + projectNumber + "/userShares"
 	userShareResult, ret := s.GetHalResource(ctx, "Projects", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status":     ret,
 			"projectUrn": projectUrn,
 			"query":      query,
@@ -90,27 +94,27 @@ func (s *Service) GetShare(ctx context.Context, projectResourceURL, userResource
 		return Share{}, ret
 	}
 	userShares := gjson.Get(string(userShareResult), "_embedded.userShares").Array()
-	for _, u := range userShares {
-		var userShare UserShare
+	if len(userShares) > 0 {
+		share.UserShares = make([]UserShare, len(userShares))
+	}
+	_, ret = FanOut(ctx, len(userShares), DefaultFanOutWorkers, FailFast, func(ctx context.Context, i int) *status.Status {
+		u := userShares[i]
 
 		// find user
 		userID := gjson.Get(u.String(), "user").String()
-		query = userResourceURL + "/search/findByUserId?userId=" + userID
+		query := FindByUserID(userResourceURL, userID).String()
 		userResult, ret := s.GetHalResourceWithServiceUser(ctx, "Users", query)
 		if ret != nil {
-			log.WithFields(event.Fields{
+			log.WithContext(ctx).WithFields(event.Fields{
 				"status": ret,
 				"userID": userID,
 				"query":  query,
 			}).Error("Failed to get user information")
-
-			ret.Message = "Cannot not get user information. Please make sure you have the correct access rights."
-			return Share{}, ret
+			return ret
 		}
-		var user User
-		json.Unmarshal(userResult, &user)
-		userShare.User = user
 
+		userShare := &share.UserShares[i]
+		json.Unmarshal(userResult, &userShare.User)
 		if gjson.Get(u.String(), "action").String() == readAction {
 			userShare.Read = true
 			userShare.Write = false
@@ -118,7 +122,11 @@ func (s *Service) GetShare(ctx context.Context, projectResourceURL, userResource
 			userShare.Read = false
 			userShare.Write = true
 		}
-		share.UserShares = append(share.UserShares, userShare)
+		return nil
+	})
+	if ret != nil {
+		ret.Message = "Cannot not get user information. Please make sure you have the correct access rights."
+		return Share{}, ret
 	}
 
 	return share, nil
@@ -136,7 +144,7 @@ func (s *Service) UpdateShare(ctx context.Context, projectResourceURL, userResou
 	val := share.PublicShare
 	_, ret = s.PatchHalResource(ctx, "Projects", query, PublicShare{Shared: *val})
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status":     ret,
 			"projectUrn": projectUrn,
 			"query":      query,
@@ -157,12 +165,12 @@ func (s *Service) CreateOrUpdateUserShare(ctx context.Context, projectResourceUR
 
 	var query string
 	if userShare.User.Email != "" {
-		query = userResourceURL + "/search/findUserIdByEmail?email=" + userShare.User.Email
+		query = FindUserIDByEmail(userResourceURL, userShare.User.Email).String()
 	} else {
 		if userShare.User.UserName != "" {
-			query = userResourceURL + "/search/findUserIdByUsername?username=" + userShare.User.UserName
+			query = FindUserIDByUsername(userResourceURL, userShare.User.UserName).String()
 		} else {
-			log.WithFields(event.Fields{
+			log.WithContext(ctx).WithFields(event.Fields{
 				"projectUrn": projectUrn,
 			}).Error("No email address or username for user sharing.")
 			return userShare, status.NewStatus([]byte{}, http.StatusBadRequest, "No email address or username found.")
@@ -171,7 +179,7 @@ func (s *Service) CreateOrUpdateUserShare(ctx context.Context, projectResourceUR
 
 	userResult, ret := s.GetHalResource(ctx, "Users", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status":     ret,
 			"projectUrn": projectUrn,
 			"email":      userShare.User.Email,
@@ -199,7 +207,7 @@ func (s *Service) CreateOrUpdateUserShare(ctx context.Context, projectResourceUR
 			query = projectResourceURL + "/" + projectNumber + "/userShares/" + userShare.User.UserID
 			_, ret = s.PatchHalResource(ctx, "Projects", query, share)
 			if ret != nil {
-				log.WithFields(event.Fields{
+				log.WithContext(ctx).WithFields(event.Fields{
 					"status":     ret,
 					"projectUrn": projectUrn,
 					"query":      query,
@@ -209,7 +217,7 @@ func (s *Service) CreateOrUpdateUserShare(ctx context.Context, projectResourceUR
 				return UserShare{}, ret
 			}
 		} else {
-			log.WithFields(event.Fields{
+			log.WithContext(ctx).WithFields(event.Fields{
 				"status":     ret,
 				"projectUrn": projectUrn,
 				"query":      query,
@@ -233,7 +241,7 @@ func (s *Service) DeleteUserShare(ctx context.Context, resourceURL, projectUrn,
 	query := resourceURL + "/" + projectNumber + "/userShares/" + userID
 	ret = s.DeleteHalResource(ctx, "Projects", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status":     ret,
 			"projectUrn": projectUrn,
 			"query":      query,
@@ -0,0 +1,85 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/roboticeyes/gococo/trace"
+)
+
+// startSpan starts the span of a call and propagates it to REXos. The span is a child of the
+// trace context of the ContextData.
+func startSpan(ctx context.Context, req *http.Request) *trace.Span {
+	var parent trace.SpanContext
+	if data, ok := ctx.Value(ContextDataKey).(ContextData); ok {
+		parent = data.Trace
+	}
+
+	resourceName := resourceNameFromContext(ctx)
+	span := trace.StartClientSpan(parent, req.Method+" "+resourceName)
+	span.SetAttribute("rexos.resource", resourceName)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url_template", urlTemplate(req.URL))
+	span.Context().Inject(req.Header)
+	return span
+}
+
+// finishSpan records the result of the call and ends the span
+func finishSpan(span *trace.Span, resp *http.Response, trials int, err error) {
+	if resp != nil {
+		span.SetAttribute("http.status_code", strconv.Itoa(resp.StatusCode))
+	}
+	span.SetAttribute("rexos.trials", strconv.Itoa(trials))
+	span.SetError(err)
+	span.Finish()
+}
+
+// urlTemplate returns the URL without identifiers and query values, so that calls of the same
+// endpoint share the template, e.g. https://rex.test/api/v2/projects/{id}{?projection}
+func urlTemplate(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, segment := range segments {
+		if isIdentifier(segment) {
+			segments[i] = "{id}"
+		}
+	}
+
+	template := u.Scheme + "://" + u.Host + strings.Join(segments, "/")
+	query := u.Query()
+	if len(query) == 0 {
+		return template
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return template + "{?" + strings.Join(names, ",") + "}"
+}
+
+// isIdentifier returns true for numeric IDs and UUIDs
+func isIdentifier(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if _, err := strconv.ParseUint(segment, 10, 64); err == nil {
+		return true
+	}
+	if len(segment) != 36 {
+		return false
+	}
+	for i, c := range segment {
+		if i == 8 || i == 13 || i == 18 || i == 23 {
+			if c != '-' {
+				return false
+			}
+		} else if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
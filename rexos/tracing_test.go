@@ -0,0 +1,64 @@
+package rexos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/roboticeyes/gococo/trace"
+)
+
+type recordingExporter struct {
+	mutex sync.Mutex
+	spans []*trace.Span
+}
+
+func (e *recordingExporter) Export(span *trace.Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func TestTracePropagation(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get(trace.TraceparentHeader)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	exporter := &recordingExporter{}
+	trace.SetExporter(exporter)
+	defer trace.SetExporter(nil)
+
+	parent, _ := trace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := context.WithValue(context.Background(), ContextDataKey, ContextData{AccessToken: "Bearer test", Trace: parent})
+
+	service := NewService(Config{NotApplyServiceUser: true})
+	service.GetResource(ctx, "Project", server.URL+"/api/v2/projects/42?projection=detailedProject")
+
+	if len(exporter.spans) != 1 {
+		t.Fatal("Wrong number of spans", len(exporter.spans))
+	}
+	span := exporter.spans[0]
+	if span.TraceID != parent.TraceID || span.ParentSpanID != parent.SpanID {
+		t.Fatal("Span is not part of the trace", span)
+	}
+	if traceparent != span.Context().Traceparent() {
+		t.Fatal("Wrong traceparent", traceparent)
+	}
+	if span.Attributes["rexos.resource"] != "Project" || span.Attributes["http.status_code"] != "404" ||
+		span.Attributes["http.url_template"] != server.URL+"/api/v2/projects/{id}{?projection}" {
+		t.Fatal("Wrong attributes", span.Attributes)
+	}
+}
+
+func TestURLTemplate(t *testing.T) {
+	u, _ := url.Parse("https://rex.test/api/v2/users/8a6e0804-2bd0-4672-b79d-d97027f9071a/shares?size=10&page=2")
+	if template := urlTemplate(u); template != "https://rex.test/api/v2/users/{id}/shares{?page,size}" {
+		t.Fatal("Wrong template", template)
+	}
+}
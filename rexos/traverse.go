@@ -0,0 +1,231 @@
+package rexos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/roboticeyes/gococo/hal"
+	"github.com/roboticeyes/gococo/status"
+	"github.com/tidwall/gjson"
+)
+
+// Traversal navigates REXos resources by following the links of their HAL documents, e.g.
+//
+//	s.TraverseUrn(projectResourceURL, projectUrn).
+//		Follow("rexReferences").Where("type", "portal").
+//		Follow("projectFiles").Where("name", "model.rex").
+//		URL(ctx, "file")
+//
+// A traversal is immutable, every step returns a new traversal which shares the preceding
+// steps. The resources are fetched with the given context when the traversal is resolved by
+// Fetch, Get, Decode, Resource or URL. The first failing step aborts the traversal.
+type Traversal struct {
+	service *Service
+	url     string
+	name    string
+	doc     []byte // document of url, nil if it has not been fetched yet
+	opts    []CallOption
+	steps   []step
+}
+
+// step is a single step of a traversal, either following a link or selecting an embedded
+// resource
+type step struct {
+	rel   string
+	vars  map[string]interface{}
+	field string
+	value string
+}
+
+// Traverse starts a traversal at the resource with the given URL
+func (s *Service) Traverse(url string) *Traversal {
+	return &Traversal{service: s, url: url, name: "Resource"}
+}
+
+// TraverseUrn starts a traversal at the resource with the given URN, which is searched in the
+// given resource, e.g. the projects resource for a project URN
+func (s *Service) TraverseUrn(resourceURL, urn string) *Traversal {
+	t := s.Traverse(QueryFindByUrn(resourceURL, urn))
+	if parts := strings.Split(urn, ":"); len(parts) > 2 && parts[1] != "" {
+		t.name = strings.ToUpper(parts[1][:1]) + parts[1][1:]
+	}
+	return t
+}
+
+// TraverseFrom starts a traversal at a HAL document which has already been fetched
+func (s *Service) TraverseFrom(document []byte) *Traversal {
+	t := s.Traverse(GetSelfLinkFromHal(document))
+	t.doc = document
+	return t
+}
+
+// Named sets the resource name of the start of the traversal, which is used for logging and
+// metrics. The following resources are named by their relation.
+func (t *Traversal) Named(resourceName string) *Traversal {
+	c := t.clone()
+	c.name = resourceName
+	return c
+}
+
+// AsServiceUser makes the traversal fetch all resources with the service user
+func (t *Traversal) AsServiceUser() *Traversal {
+	return t.WithOptions(AsServiceUser())
+}
+
+// WithOptions sets the call options for fetching the resources of the traversal
+func (t *Traversal) WithOptions(opts ...CallOption) *Traversal {
+	c := t.clone()
+	c.opts = append(append([]CallOption(nil), t.opts...), opts...)
+	return c
+}
+
+// Follow follows the links with the given relations. Templated links are followed with all
+// template variables removed.
+func (t *Traversal) Follow(rels ...string) *Traversal {
+	c := t.clone()
+	for _, rel := range rels {
+		c.steps = append(c.steps, step{rel: rel})
+	}
+	return c
+}
+
+// FollowWith follows the link with the given relation, whose template is expanded with the
+// variables, e.g. {"projection": "detailed"}
+func (t *Traversal) FollowWith(rel string, vars map[string]interface{}) *Traversal {
+	c := t.clone()
+	c.steps = append(c.steps, step{rel: rel, vars: vars})
+	return c
+}
+
+// Where selects the first embedded resource of the current resource whose field (a gjson path)
+// has the given value, e.g. the reference of type "portal" of a rexReferences collection
+func (t *Traversal) Where(field, value string) *Traversal {
+	c := t.clone()
+	c.steps = append(c.steps, step{field: field, value: value})
+	return c
+}
+
+// Fetch resolves the traversal and returns a traversal which starts at the resulting resource.
+// This allows following several links of a resource without fetching it again. The URL of the
+// returned traversal is the self link of the resource, e.g. the project of a findByUrn search.
+func (t *Traversal) Fetch(ctx context.Context) (*Traversal, *status.Status) {
+	url, name, doc, ret := t.resolve(ctx, true)
+	if ret != nil {
+		return nil, ret
+	}
+	if self := GetSelfLinkFromHal(doc); self != "" {
+		url = self
+	}
+	return &Traversal{service: t.service, url: url, name: name, doc: doc, opts: t.opts}, nil
+}
+
+// Get resolves the traversal and returns the resulting resource
+func (t *Traversal) Get(ctx context.Context) ([]byte, *status.Status) {
+	_, _, doc, ret := t.resolve(ctx, true)
+	return doc, ret
+}
+
+// Decode resolves the traversal and decodes the resulting resource into v
+func (t *Traversal) Decode(ctx context.Context, v interface{}) *status.Status {
+	doc, ret := t.Get(ctx)
+	if ret != nil {
+		return ret
+	}
+	if err := json.Unmarshal(doc, v); err != nil {
+		return status.NewStatus([]byte{}, http.StatusInternalServerError, "Cannot decode resource: "+err.Error())
+	}
+	return nil
+}
+
+// Resource resolves the traversal and returns the resulting HAL resource
+func (t *Traversal) Resource(ctx context.Context) (*hal.Resource, *status.Status) {
+	doc, ret := t.Get(ctx)
+	if ret != nil {
+		return nil, ret
+	}
+	r, err := hal.Parse(doc)
+	if err != nil {
+		return nil, status.NewStatus([]byte{}, http.StatusBadGateway, "Invalid HAL resource: "+err.Error())
+	}
+	return r, nil
+}
+
+// URL resolves the traversal without fetching the last resource and returns its URL. If
+// relations are given they are followed first, e.g. URL(ctx, "file") returns the download URL
+// of a project file.
+func (t *Traversal) URL(ctx context.Context, rels ...string) (string, *status.Status) {
+	url, _, _, ret := t.Follow(rels...).resolve(ctx, false)
+	return url, ret
+}
+
+func (t *Traversal) clone() *Traversal {
+	c := *t
+	c.steps = append([]step(nil), t.steps...)
+	return &c
+}
+
+// resolve performs the steps and returns the URL, the name and, if fetched, the document of the
+// resulting resource
+func (t *Traversal) resolve(ctx context.Context, fetchLast bool) (string, string, []byte, *status.Status) {
+	url, name, doc := t.url, t.name, t.doc
+	var ret *status.Status
+	for _, st := range t.steps {
+		if doc == nil {
+			if doc, ret = t.service.GetResource(ctx, name, url, t.opts...); ret != nil {
+				return url, name, nil, ret
+			}
+		}
+		r, err := hal.Parse(doc)
+		if err != nil {
+			return url, name, nil, status.NewStatus([]byte{}, http.StatusBadGateway, "Invalid HAL resource "+url+": "+err.Error())
+		}
+
+		if st.rel == "" {
+			selected, ok := selectEmbedded(r, st.field, st.value)
+			if !ok {
+				return url, name, nil, status.NewStatus([]byte{}, http.StatusNotFound, "No resource with "+st.field+" "+st.value+" embedded in "+url)
+			}
+			doc, _ = selected.MarshalJSON()
+			url = selected.Self()
+			continue
+		}
+
+		link, ok := r.Link(st.rel)
+		if !ok {
+			return url, name, nil, status.NewStatus([]byte{}, http.StatusNotFound, "No link "+st.rel+" in "+url)
+		}
+		// REXos does not mark all links with template parameters as templated
+		if url, err = hal.Expand(link.Href, st.vars); err != nil {
+			return link.Href, name, nil, status.NewStatus([]byte{}, http.StatusBadGateway, "Invalid link "+st.rel+": "+err.Error())
+		}
+		name, doc = st.rel, nil
+	}
+
+	if fetchLast && doc == nil {
+		if doc, ret = t.service.GetResource(ctx, name, url, t.opts...); ret != nil {
+			return url, name, nil, ret
+		}
+	}
+	return url, name, doc, nil
+}
+
+// selectEmbedded returns the first embedded resource whose field has the given value
+func selectEmbedded(r *hal.Resource, field, value string) (*hal.Resource, bool) {
+	rels := make([]string, 0, len(r.Embedded))
+	for rel := range r.Embedded {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		for _, e := range r.Embedded[rel] {
+			doc, _ := e.MarshalJSON()
+			if gjson.GetBytes(doc, field).String() == value {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
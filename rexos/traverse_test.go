@@ -0,0 +1,82 @@
+package rexos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTraversal(t *testing.T) {
+	var paths []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		base := server.URL + "/api/v2"
+		switch r.URL.Path {
+		case "/api/v2/projects/search/findByUrn":
+			w.Write([]byte(`{"name":"Machine 1","_links":{
+				"self":{"href":"` + base + `/projects/1"},
+				"rexReferences":{"href":"` + base + `/projects/1/rexReferences{?projection}","templated":true}}}`))
+		case "/api/v2/projects/1/rexReferences":
+			w.Write([]byte(`{"_embedded":{"rexReferences":[
+				{"type":"root","_links":{"self":{"href":"` + base + `/rexReferences/2"}}},
+				{"type":"portal","key":"abc","_links":{"self":{"href":"` + base + `/rexReferences/3"},
+					"projectFiles":{"href":"` + base + `/rexReferences/3/projectFiles"}}}]}}`))
+		case "/api/v2/rexReferences/3/projectFiles":
+			w.Write([]byte(`{"_embedded":{"projectFiles":[
+				{"name":"model.rex","type":"rex","_links":{"self":{"href":"` + base + `/projectFiles/4"},
+					"file":{"href":"` + base + `/projectFiles/4/file"}}}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	projectFiles := service.TraverseUrn(server.URL+"/api/v2/projects", "robotic-eyes:project:1").
+		FollowWith("rexReferences", map[string]interface{}{"projection": "linked"}).
+		Where("type", "portal").
+		Follow("projectFiles")
+
+	fileURL, ret := projectFiles.Where("name", "model.rex").URL(testContext(), "file")
+	if ret != nil || fileURL != server.URL+"/api/v2/projectFiles/4/file" {
+		t.Fatal("Wrong file URL", fileURL, ret)
+	}
+	if len(paths) != 3 || paths[1] != "/api/v2/projects/1/rexReferences?projection=linked" {
+		t.Fatal("Wrong requests", paths)
+	}
+
+	var file ProjectFile
+	if ret := projectFiles.Where("name", "model.rex").Decode(testContext(), &file); ret != nil || file.Type != "rex" {
+		t.Fatal("Wrong project file", file, ret)
+	}
+
+	// a fetched resource is not fetched again when following its links
+	paths = nil
+	project, ret := service.TraverseUrn(server.URL+"/api/v2/projects", "robotic-eyes:project:1").Fetch(testContext())
+	if ret != nil {
+		t.Fatal("Fetch failed", ret)
+	}
+	if u, _ := project.URL(testContext()); u != server.URL+"/api/v2/projects/1" {
+		t.Fatal("Wrong project URL", u)
+	}
+	if _, ret := project.Follow("rexReferences").Get(testContext()); ret != nil {
+		t.Fatal("Follow failed", ret)
+	}
+	if len(paths) != 2 || !strings.HasSuffix(paths[1], "/rexReferences") {
+		t.Fatal("Wrong requests", paths)
+	}
+
+	if _, ret := project.Follow("userShares").Get(testContext()); ret == nil || ret.Code != http.StatusNotFound {
+		t.Fatal("Missing link followed", ret)
+	}
+	if _, ret := project.Follow("rexReferences").Where("type", "website").Get(testContext()); ret == nil || ret.Code != http.StatusNotFound {
+		t.Fatal("Missing resource selected", ret)
+	}
+
+	// all hops are fetched with the service user, which is not initialized
+	if _, ret := project.AsServiceUser().Follow("rexReferences").Get(testContext()); ret == nil || ret.Code != http.StatusForbidden {
+		t.Fatal("Service user not applied", ret)
+	}
+}
@@ -0,0 +1,87 @@
+package rexos
+
+import (
+	"io"
+	"mime/multipart"
+	"sort"
+)
+
+// MultipartUpload describes a file which is uploaded as multipart form. The content is streamed
+// to REXos and never held in memory.
+type MultipartUpload struct {
+	// FileName is the name of the file part
+	FileName string
+
+	// Content is the data of the file
+	Content io.Reader
+
+	// ContentLength is the size of the content. If it is known (> 0), the request is sent with
+	// a content length, otherwise with chunked encoding.
+	ContentLength int64
+
+	// Fields are additional form fields (e.g. meta data) which are sent before the file
+	Fields map[string]string
+}
+
+// payload returns the streamed multipart body and its content type. The body is written by a
+// goroutine which stops as soon as the returned reader is closed.
+func (u MultipartUpload) payload() (io.Reader, io.Closer, string) {
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	var payload io.Reader = pr
+	if u.ContentLength > 0 {
+		payload = &sizedReader{Reader: pr, size: u.size(writer.Boundary())}
+	}
+
+	go func() {
+		pw.CloseWithError(u.write(writer, u.Content))
+	}()
+	return payload, pr, writer.FormDataContentType()
+}
+
+// size returns the size of the multipart body for the given boundary
+func (u MultipartUpload) size(boundary string) int64 {
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	writer.SetBoundary(boundary)
+	u.write(writer, nil)
+	return counter.n + u.ContentLength
+}
+
+// write writes the fields and the file part with the given content
+func (u MultipartUpload) write(writer *multipart.Writer, content io.Reader) error {
+
+	keys := make([]string, 0, len(u.Fields))
+	for k := range u.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := writer.WriteField(k, u.Fields[k]); err != nil {
+			return err
+		}
+	}
+
+	part, err := writer.CreateFormFile("file", u.FileName)
+	if err != nil {
+		return err
+	}
+	if content != nil {
+		if _, err := io.Copy(part, content); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// countingWriter counts the written bytes
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
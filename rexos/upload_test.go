@@ -0,0 +1,40 @@
+package rexos
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength <= 0 {
+			t.Error("Missing content length")
+		}
+		if r.FormValue("name") != "scan" {
+			t.Error("Wrong form field", r.FormValue("name"))
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := ioutil.ReadAll(file)
+		if header.Filename != "scan.rex" || string(data) != "content" {
+			t.Error("Wrong file", header.Filename, string(data))
+		}
+	}))
+	defer server.Close()
+
+	service := NewServiceWithClient(NewClient(Config{NotApplyServiceUser: true}))
+	ret := service.UploadMultipart(testContext(), server.URL, MultipartUpload{
+		FileName:      "scan.rex",
+		Content:       strings.NewReader("content"),
+		ContentLength: 7,
+		Fields:        map[string]string{"name": "scan"},
+	})
+	if ret != nil {
+		t.Fatal("Upload failed", ret)
+	}
+}
@@ -58,7 +58,7 @@ func (s *Service) GetCurrentUser(ctx context.Context, resourceURL string) (UserI
 
 	currentUserResult, ret := s.GetHalResourceNoXF(ctx, "User", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status": ret,
 			"query":  query,
 		}).Error("Failed to get current user")
@@ -68,10 +68,10 @@ func (s *Service) GetCurrentUser(ctx context.Context, resourceURL string) (UserI
 	}
 
 	// get user properties name, email
-	userResultLink := StripTemplateParameter(gjson.Get(string(currentUserResult), "_links.user.href").String())
+	userResultLink := getLinkFromHal(currentUserResult, "user")
 	userResult, ret := s.GetHalResourceNoXF(ctx, "User", userResultLink)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status": ret,
 			"query":  userResultLink,
 		}).Error("Failed to get user information")
@@ -91,17 +91,17 @@ func (s *Service) GetUserStatistics(ctx context.Context, resourceURL string) (Us
 	// get userID
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"error": err.Error(),
 		}).Error("Failed to get userID")
 
 		return UserStatistics{}, status.NewStatus([]byte{}, http.StatusInternalServerError, "Cannot get userID ")
 	}
 
-	query := resourceURL + "/statisticsByUser?userId=" + userID
+	query := NewQuery(resourceURL+"/statisticsByUser").Param("userId", userID).String()
 	userStatisticsResult, ret := s.GetHalResource(ctx, "Project", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status": ret,
 			"query":  query,
 		}).Error("Failed to get current user statistics")
@@ -121,7 +121,7 @@ func (s *Service) GetUserLicenses(ctx context.Context, resourceURL string) (User
 
 	currentUserResult, ret := s.GetHalResource(ctx, "User", query)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status": ret,
 			"query":  query,
 		}).Error("Failed to get current user")
@@ -130,10 +130,10 @@ func (s *Service) GetUserLicenses(ctx context.Context, resourceURL string) (User
 		return UserLicenses{}, ret
 	}
 
-	userLicensesLink := StripTemplateParameter(gjson.Get(string(currentUserResult), "_links.userLicenses.href").String())
+	userLicensesLink := getLinkFromHal(currentUserResult, "userLicenses")
 	userLicensesResult, ret := s.GetHalResource(ctx, "User", userLicensesLink)
 	if ret != nil {
-		log.WithFields(event.Fields{
+		log.WithContext(ctx).WithFields(event.Fields{
 			"status": ret,
 			"query":  userLicensesLink,
 		}).Error("Failed to get user licenses")
@@ -151,7 +151,7 @@ func (s *Service) GetUserLicenses(ctx context.Context, resourceURL string) (User
 		licenseLink := gjson.Get(l.String(), "_links.license.href").String()
 		licenseResult, ret := s.GetHalResource(ctx, "User", licenseLink)
 		if ret != nil {
-			log.WithFields(event.Fields{
+			log.WithContext(ctx).WithFields(event.Fields{
 				"status": ret,
 				"query":  licenseLink,
 			}).Error("Failed to get license")
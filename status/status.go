@@ -1,12 +1,37 @@
 package status
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// StatusClientClosedRequest is the (non-standard) code for requests which got canceled by the
+	// caller before a response was available
+	StatusClientClosedRequest = 499
+
+	// TypeDeadlineExceeded marks the internal status of a request which exceeded its deadline
+	TypeDeadlineExceeded = "DEADLINE_EXCEEDED"
+	// TypeRequestCanceled marks the internal status of a request which got canceled by the caller
+	TypeRequestCanceled = "REQUEST_CANCELED"
+	// TypeCircuitOpen marks the internal status of a request which was rejected because the
+	// circuit breaker of the target is open
+	TypeCircuitOpen = "CIRCUIT_OPEN"
+	// TypeIntegrityFailure marks the internal status of a file transfer whose content hash
+	// does not match
+	TypeIntegrityFailure = "INTEGRITY_FAILURE"
+	// TypeOptimisticLockingFailure marks the status of an update which failed because the
+	// resource got modified concurrently
+	TypeOptimisticLockingFailure = "OPTIMISTIC_LOCKING_FAILURE"
+	// TypeRateLimited marks the internal status of a request which was not sent because the
+	// outbound rate limit got exceeded
+	TypeRateLimited = "RATE_LIMITED"
+)
+
 // Status structure with code and message presentable to the user
 type Status struct {
 	Code           int         `json:"code" example:"400"`
@@ -36,6 +61,87 @@ func NewStatus(body []byte, code int, message string) *Status {
 	return status
 }
 
+// NewContextStatus creates a new object for a request which got aborted by its context. An exceeded
+// deadline results in 504 (Gateway Timeout), a cancellation in 499 (Client Closed Request). If the
+// given error is not caused by the context, nil is returned.
+func NewContextStatus(err error, message string) *Status {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Status{
+			Code:           http.StatusGatewayTimeout,
+			Message:        message + ": deadline exceeded",
+			InternalStatus: RexOSStatus{Type: TypeDeadlineExceeded, Code: http.StatusGatewayTimeout},
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		return &Status{
+			Code:           StatusClientClosedRequest,
+			Message:        message + ": request canceled",
+			InternalStatus: RexOSStatus{Type: TypeRequestCanceled, Code: StatusClientClosedRequest},
+		}
+	}
+	return nil
+}
+
+// NewCircuitOpenStatus creates a new object for a request which was not sent because the circuit
+// breaker of the target is open. The status is reported as 503 (Service Unavailable).
+func NewCircuitOpenStatus(message string) *Status {
+	return &Status{
+		Code:           http.StatusServiceUnavailable,
+		Message:        message + ": service unavailable",
+		InternalStatus: RexOSStatus{Type: TypeCircuitOpen, Code: http.StatusServiceUnavailable},
+	}
+}
+
+// NewRateLimitedStatus creates a new object for a request which was not sent because the
+// outbound rate limit got exceeded. The status is reported as 429 (Too Many Requests).
+func NewRateLimitedStatus(message string) *Status {
+	return &Status{
+		Code:           http.StatusTooManyRequests,
+		Message:        message + ": too many requests",
+		InternalStatus: RexOSStatus{Type: TypeRateLimited, Code: http.StatusTooManyRequests},
+	}
+}
+
+// NewIntegrityStatus creates a new object for a file transfer whose content hash does not match.
+// The status is reported as 502 (Bad Gateway), since the content got corrupted upstream.
+func NewIntegrityStatus(message string) *Status {
+	return &Status{
+		Code:           http.StatusBadGateway,
+		Message:        message,
+		InternalStatus: RexOSStatus{Type: TypeIntegrityFailure, Code: http.StatusBadGateway},
+	}
+}
+
+// IsDeadlineExceeded returns true if the status reports a request which did not finish
+// before the deadline of its context
+func (s *Status) IsDeadlineExceeded() bool {
+	return s != nil && s.InternalStatus.Type == TypeDeadlineExceeded
+}
+
+// IsCircuitOpen returns true if the status reports a request which was rejected by the
+// circuit breaker
+func (s *Status) IsCircuitOpen() bool {
+	return s != nil && s.InternalStatus.Type == TypeCircuitOpen
+}
+
+// IsRateLimited returns true if the status reports a request which was rejected by the
+// outbound rate limiter
+func (s *Status) IsRateLimited() bool {
+	return s != nil && s.InternalStatus.Type == TypeRateLimited
+}
+
+// IsIntegrityFailure returns true if the status reports a file transfer whose content hash
+// does not match
+func (s *Status) IsIntegrityFailure() bool {
+	return s != nil && s.InternalStatus.Type == TypeIntegrityFailure
+}
+
+// IsOptimisticLockingFailure returns true if the status reports an update which failed because
+// the resource got modified concurrently
+func (s *Status) IsOptimisticLockingFailure() bool {
+	return s != nil && s.InternalStatus.Type == TypeOptimisticLockingFailure
+}
+
 // NewHTTPStatus encapsulates a proper http error response
 func NewHTTPStatus(ctx *gin.Context, status int, err error) {
 	er := Status{
@@ -0,0 +1,220 @@
+// Package trace propagates W3C trace context (traceparent and tracestate) across composite
+// services and records spans of outbound calls. Spans are passed to the exporter set by
+// SetExporter, without exporter they are discarded.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TraceparentHeader is the header field which carries the trace and the parent span
+	TraceparentHeader = "traceparent"
+
+	// TracestateHeader is the header field which carries vendor specific trace data
+	TracestateHeader = "tracestate"
+
+	// flagSampled is the trace flag which marks the trace as recorded by the caller
+	flagSampled = 0x01
+)
+
+// SpanContext identifies a span within a trace
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+	Flags   byte
+	State   string
+}
+
+// IsValid returns true if the trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != "" && sc.SpanID != ""
+}
+
+// Traceparent returns the value of the traceparent header field
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// Inject sets the traceparent and tracestate header fields. Invalid span contexts are not
+// injected.
+func (sc SpanContext) Inject(header http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.State != "" {
+		header.Set(TracestateHeader, sc.State)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// Extract returns the span context of the traceparent and tracestate header fields. If the
+// header does not carry a valid traceparent, false is returned.
+func Extract(header http.Header) (SpanContext, bool) {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return SpanContext{}, false
+	}
+	sc.State = strings.Join(header.Values(TracestateHeader), ",")
+	return sc, true
+}
+
+// ParseTraceparent parses the value of the traceparent header field
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	if !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return SpanContext{}, false
+	}
+	if isZero(parts[1]) || isZero(parts[2]) {
+		return SpanContext{}, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return SpanContext{TraceID: parts[1], SpanID: parts[2], Flags: flags[0]}, true
+}
+
+// NewTrace returns the context of a new sampled trace without parent span
+func NewTrace() SpanContext {
+	return SpanContext{TraceID: newID(16), Flags: flagSampled}
+}
+
+// Span is a timed operation within a trace, e.g. a single REXos call including its retries
+type Span struct {
+	Name         string            `json:"name"`
+	TraceID      string            `json:"traceId"`
+	SpanID       string            `json:"spanId"`
+	ParentSpanID string            `json:"parentSpanId,omitempty"`
+	Kind         string            `json:"kind"`
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+
+	context SpanContext
+}
+
+// StartClientSpan starts a span of an outbound call. The span is a child of the given parent,
+// or the root of a new trace if the parent does not belong to a trace.
+func StartClientSpan(parent SpanContext, name string) *Span {
+	if parent.TraceID == "" {
+		parent = NewTrace()
+	}
+	s := &Span{
+		Name:         name,
+		TraceID:      parent.TraceID,
+		SpanID:       newID(8),
+		ParentSpanID: parent.SpanID,
+		Kind:         "client",
+		Start:        time.Now(),
+		Attributes:   make(map[string]string),
+	}
+	s.context = SpanContext{TraceID: s.TraceID, SpanID: s.SpanID, Flags: parent.Flags, State: parent.State}
+	return s
+}
+
+// Context returns the span context which is propagated to the callee
+func (s *Span) Context() SpanContext {
+	return s.context
+}
+
+// SetAttribute sets an attribute of the span
+func (s *Span) SetAttribute(key, value string) {
+	s.Attributes[key] = value
+}
+
+// SetError marks the span as failed
+func (s *Span) SetError(err error) {
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// Finish ends the span and passes it to the exporter. Spans of traces which are not sampled are
+// not exported.
+func (s *Span) Finish() {
+	s.End = time.Now()
+	if s.context.Flags&flagSampled == 0 {
+		return
+	}
+	if e := getExporter(); e != nil {
+		e.Export(s)
+	}
+}
+
+// Exporter receives finished spans. Exporters must be safe for concurrent use.
+type Exporter interface {
+	Export(span *Span)
+}
+
+var (
+	exporterMutex sync.RWMutex
+	exporter      Exporter
+)
+
+// SetExporter sets the exporter of all spans, nil discards them
+func SetExporter(e Exporter) {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	exporter = e
+}
+
+func getExporter() Exporter {
+	exporterMutex.RLock()
+	defer exporterMutex.RUnlock()
+	return exporter
+}
+
+// WriterExporter writes every span as a single line of JSON, e.g. to stdout where it is picked
+// up by a log based collector
+type WriterExporter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewWriterExporter creates an exporter which writes to w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{encoder: json.NewEncoder(w)}
+}
+
+// Export writes the span
+func (e *WriterExporter) Export(span *Span) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.encoder.Encode(span)
+}
+
+func newID(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for _, c := range value {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(value string) bool {
+	return strings.Trim(value, "0") == ""
+}
@@ -0,0 +1,51 @@
+package trace
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || sc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID != "00f067aa0ba902b7" || sc.Flags != 1 {
+		t.Fatal("Wrong span context", sc, ok)
+	}
+	if sc.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatal("Wrong traceparent", sc.Traceparent())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceparent(invalid); ok {
+			t.Fatal("Invalid traceparent accepted", invalid)
+		}
+	}
+}
+
+func TestChildSpanPropagation(t *testing.T) {
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(TracestateHeader, "rex=1")
+	parent, ok := Extract(header)
+	if !ok {
+		t.Fatal("Trace context not extracted")
+	}
+
+	span := StartClientSpan(parent, "GET Project")
+	if span.TraceID != parent.TraceID || span.ParentSpanID != parent.SpanID || span.SpanID == parent.SpanID {
+		t.Fatal("Span is not a child of the parent", span)
+	}
+
+	outbound := http.Header{}
+	span.Context().Inject(outbound)
+	if outbound.Get(TraceparentHeader) != "00-"+parent.TraceID+"-"+span.SpanID+"-01" || outbound.Get(TracestateHeader) != "rex=1" {
+		t.Fatal("Wrong propagated header", outbound)
+	}
+}